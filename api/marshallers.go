@@ -19,6 +19,21 @@ func (r BadRequestApplicationProblemPlusJSONResponse) MarshalJSON() ([]byte, err
 	return p.MarshalJSON()
 }
 
+func (r ConflictApplicationProblemPlusJSONResponse) MarshalJSON() ([]byte, error) {
+	p := ProblemDetail(r)
+	return p.MarshalJSON()
+}
+
+func (r PreconditionFailedApplicationProblemPlusJSONResponse) MarshalJSON() ([]byte, error) {
+	p := ProblemDetail(r)
+	return p.MarshalJSON()
+}
+
+func (r UnprocessableEntityApplicationProblemPlusJSONResponse) MarshalJSON() ([]byte, error) {
+	p := ProblemDetail(r)
+	return p.MarshalJSON()
+}
+
 func (p ProblemDetail) MarshalJSON() ([]byte, error) {
 	type Alias ProblemDetail
 	var errStr string
@@ -49,6 +64,8 @@ func (p *ProblemDetail) UnmarshalJSON(data []byte) error {
 	p.Status = res.Status
 	p.Instance = res.Instance
 	p.TraceId = res.TraceId
+	p.Errors = res.Errors
+	p.InvalidParams = res.InvalidParams
 	p.Detail = errors.New(res.Detail)
 	return nil
 }
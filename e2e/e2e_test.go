@@ -2,7 +2,7 @@ package e2e
 
 import (
 	"context"
-	"errors"
+	"fmt"
 	"net/http"
 	"testing"
 
@@ -20,29 +20,66 @@ func TestE2E_Should_Verify_User_Flow(t *testing.T) {
 	userToCreate := api.UserV1{
 		Name: faker.Name(),
 	}
-	createUserRes, err := client.CreateUserWithResponse(ctx, userToCreate)
+	idempotencyKey := faker.UUIDHyphenated()
+	createUserParams := &api.CreateUserParams{IdempotencyKey: &idempotencyKey}
+	createUserRes, err := client.CreateUserWithResponse(ctx, createUserParams, userToCreate)
 	require.NoError(t, err)
 	require.Equal(t, http.StatusCreated, createUserRes.StatusCode())
+	require.NotNil(t, createUserRes.JSON201)
+	user := *createUserRes.JSON201
+	require.Equal(t, userToCreate.Name, user.Name)
+	require.Equal(t, fmt.Sprintf("/api/users/v1/%d", user.Id), createUserRes.HTTPResponse.Header.Get("Location"))
+
+	// We get the same response when creation is retried with the same idempotency key
+	createUserRes, err = client.CreateUserWithResponse(ctx, createUserParams, userToCreate)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, createUserRes.StatusCode())
+	require.Equal(t, "true", createUserRes.HTTPResponse.Header.Get("Idempotent-Replayed"))
+	require.Equal(t, user, *createUserRes.JSON201)
+
+	// We get 422 when the idempotency key is reused for another user
+	createUserRes, err = client.CreateUserWithResponse(ctx, createUserParams, api.UserV1{Name: faker.Name()})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusUnprocessableEntity, createUserRes.StatusCode())
 
 	// We check the user is in the all users list
 	getUsersRes, err := client.GetUsersWithResponse(ctx)
 	require.NoError(t, err)
 	require.Equal(t, http.StatusOK, getUsersRes.StatusCode())
-	require.Greater(t, len(*getUsersRes.JSON200), 0)
-	user, err := findUser(userToCreate.Name, *getUsersRes.JSON200)
-	require.NoError(t, err)
+	require.Contains(t, *getUsersRes.JSON200, user)
 
 	// We check the user can be fetched
-	getUserRes, err := client.GetUserWithResponse(ctx, user.Id)
+	getUserRes, err := client.GetUserWithResponse(ctx, user.Id, &api.GetUserParams{})
 	require.NoError(t, err)
 	require.Equal(t, http.StatusOK, getUserRes.StatusCode())
 	require.Equal(t, user.Id, getUserRes.JSON200.Id)
 	require.Equal(t, user.Name, getUserRes.JSON200.Name)
+	etag := getUserRes.HTTPResponse.Header.Get("ETag")
+	require.NotEmpty(t, etag)
+	require.NotEmpty(t, getUserRes.HTTPResponse.Header.Get("Last-Modified"))
+
+	// We get 304 when the cached user is current
+	getUserRes, err = client.GetUserWithResponse(ctx, user.Id, &api.GetUserParams{IfNoneMatch: &etag})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusNotModified, getUserRes.StatusCode())
+
+	// We update the user based on its current version
+	updatedName := faker.Name()
+	updateUserRes, err := client.UpdateUserWithResponse(ctx, user.Id, &api.UpdateUserParams{IfMatch: &etag}, api.UserV1{Name: updatedName})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, updateUserRes.StatusCode())
+	require.Equal(t, updatedName, updateUserRes.JSON200.Name)
+	require.NotEqual(t, etag, updateUserRes.HTTPResponse.Header.Get("ETag"))
+
+	// We get 412 when the update is based on a stale version
+	updateUserRes, err = client.UpdateUserWithResponse(ctx, user.Id, &api.UpdateUserParams{IfMatch: &etag}, api.UserV1{Name: faker.Name()})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusPreconditionFailed, updateUserRes.StatusCode())
 
 	// We get 404 for user that doesn't exist
 	rndInts, err := faker.RandomInt(0, 9999)
 	require.NoError(t, err)
-	getUserRes, err = client.GetUserWithResponse(ctx, int32(rndInts[0]))
+	getUserRes, err = client.GetUserWithResponse(ctx, int32(rndInts[0]), &api.GetUserParams{})
 	require.NoError(t, err)
 	require.Equal(t, http.StatusNotFound, getUserRes.StatusCode())
 
@@ -50,17 +87,18 @@ func TestE2E_Should_Verify_User_Flow(t *testing.T) {
 	userToCreate = api.UserV1{
 		Name: "",
 	}
-	createUserRes, err = client.CreateUserWithResponse(ctx, userToCreate)
+	createUserRes, err = client.CreateUserWithResponse(ctx, &api.CreateUserParams{}, userToCreate)
 	require.NoError(t, err)
 	require.Equal(t, http.StatusBadRequest, createUserRes.StatusCode())
 	require.NotNil(t, createUserRes.ApplicationproblemJSON400)
-}
 
-func findUser(name string, users []api.UserV1) (api.UserV1, error) {
-	for i := range users {
-		if users[i].Name == name {
-			return users[i], nil
-		}
-	}
-	return api.UserV1{}, errors.New("not found")
+	// We get users list in xml when it is preferred
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://localhost:8080/api/users/v1", nil)
+	require.NoError(t, err)
+	req.Header.Set("Accept", "application/xml")
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	require.Equal(t, "application/xml", res.Header.Get("Content-Type"))
 }
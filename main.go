@@ -17,7 +17,7 @@ func main() {
 	}
 
 	go func() {
-		if err := app.Start(); err != nil {
+		if err := integration.RunRecovered("app", app.Start); err != nil {
 			slog.Error("failed to start app", "err", err)
 			os.Exit(1)
 		}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 
 	"github.com/alexliesenfeld/health"
+	"go.opentelemetry.io/otel/sdk/log"
 	"go.opentelemetry.io/otel/sdk/metric"
 	"go.opentelemetry.io/otel/sdk/trace"
 	"golang-http-service/pkg/business/boundary"
@@ -24,6 +25,8 @@ type app struct {
 	apiServer      integration.HttpServer
 	traceProvider  *trace.TracerProvider
 	metricProvider *metric.MeterProvider
+	logProvider    *log.LoggerProvider
+	logLevels      *integration.LogLevels
 }
 
 func NewApp() (App, error) {
@@ -33,22 +36,32 @@ func NewApp() (App, error) {
 		return nil, fmt.Errorf("failed to populate config; %w", err)
 	}
 
-	telemetryResource, err := integration.CreateTelemetryResource(ctx)
+	resourceCfg := app.config.Telemetry.Resource
+	telemetryResource, err := integration.CreateTelemetryResource(ctx, resourceCfg.ServiceName, resourceCfg.ServiceNamespace, resourceCfg.ServiceVersion, resourceCfg.Environment, resourceCfg.Detectors)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create telemetry resource; %w", err)
 	}
 
-	if err := integration.ConfigureLogProvider(telemetryResource, app.config.Telemetry.Logs.Level, app.config.Telemetry.Logs.Format); err != nil {
+	redactor, err := integration.ConfigureRedaction(app.config.Redaction)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure redaction; %w", err)
+	}
+
+	logsCfg := app.config.Telemetry.Logs
+	if lp, levels, err := integration.ConfigureLogProvider(ctx, telemetryResource, logsCfg.Level, logsCfg.Loggers, logsCfg.Format, logsCfg.Output, logsCfg.Otlp, redactor); err != nil {
 		return nil, fmt.Errorf("failed to init log provider; %w", err)
+	} else {
+		app.logProvider = lp
+		app.logLevels = levels
 	}
 
-	if tp, err := integration.ConfigureTraceProvider(ctx, telemetryResource, app.config.Telemetry.Traces.Output); err != nil {
+	if tp, err := integration.ConfigureTraceProvider(ctx, telemetryResource, app.config.Telemetry.Traces.Output, app.config.Telemetry.Traces.Otlp, app.config.Telemetry.Traces.Sampling, redactor); err != nil {
 		return nil, fmt.Errorf("failed to init tracer; %w", err)
 	} else {
 		app.traceProvider = tp
 	}
 
-	if mp, err := integration.ConfigureMetricProvider(ctx, telemetryResource, app.config.Telemetry.Metrics.Output); err != nil {
+	if mp, err := integration.ConfigureMetricProvider(ctx, telemetryResource, app.config.Telemetry.Metrics.Output, app.config.Telemetry.Metrics.Otlp); err != nil {
 		return nil, fmt.Errorf("failed to init metric provider; %w", err)
 	} else {
 		app.metricProvider = mp
@@ -59,15 +72,28 @@ func NewApp() (App, error) {
 		return nil, fmt.Errorf("failed to create harbor client; %w", err)
 	}
 
-	userRepo := control.NewUserRepo()
-	controller := boundary.NewController(userRepo)
+	businessMetrics, err := integration.NewBusinessMetrics(app.metricProvider.Meter("golang-http-service"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create business metrics; %w", err)
+	}
+	tracer := app.traceProvider.Tracer("golang-http-service")
+	userRepo := control.NewTracingUserRepo(control.NewUserRepo(businessMetrics), tracer)
+	controller := boundary.NewController(userRepo, businessMetrics)
 
 	roleDefs := make(map[string]string)
 	for _, role := range app.config.Auth.Roles {
 		roleDefs[role.Name] = role.Audience
 	}
 
-	apiHandler, err := integration.APIHandler(app.config.BaseUrl, controller, app.config.Auth.Enabled, app.config.Auth.JwkSetUri, app.config.Auth.AllowedIssuers, roleDefs)
+	apiHandler, err := integration.APIHandler(controller, integration.APIOptions{
+		BaseURL:         app.config.BaseUrl,
+		Http:            app.config.Http,
+		Auth:            app.config.Auth.Enabled,
+		JwkSetURI:       app.config.Auth.JwkSetUri,
+		AllowedIssuers:  app.config.Auth.AllowedIssuers,
+		RoleDefs:        roleDefs,
+		DurationBuckets: app.config.Telemetry.Metrics.DurationBuckets,
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create api handler; %w", err)
 	}
@@ -78,18 +104,18 @@ func NewApp() (App, error) {
 		Check: func(ctx context.Context) error { return nil },
 	}
 
-	app.actuatorServer = integration.NewHttpServer(app.config.Actuator.Port, integration.TelemetryHandler(exampleCheck))
+	app.actuatorServer = integration.NewHttpServer(app.config.Actuator.Port, integration.TelemetryHandler(app.logLevels, exampleCheck))
 	return &app, nil
 }
 
 func (a *app) Start() error {
-	starters := []func() error{
-		a.actuatorServer.Start,
-		a.apiServer.Start,
+	starters := map[string]func() error{
+		"actuator server": a.actuatorServer.Start,
+		"api server":      a.apiServer.Start,
 	}
 	done := make(chan error, len(starters))
-	for i := range starters {
-		go func() { done <- starters[i]() }()
+	for name, starter := range starters {
+		go func() { done <- integration.RunSupervised(name, starter, integration.DefaultRestartPolicy) }()
 	}
 	for i := 0; i < cap(done); i++ {
 		if err := <-done; err != nil {
@@ -106,5 +132,6 @@ func (a *app) Stop() error {
 		a.apiServer.Stop(ctx),
 		a.traceProvider.Shutdown(ctx),
 		a.metricProvider.Shutdown(ctx),
+		a.logProvider.Shutdown(ctx),
 	)
 }
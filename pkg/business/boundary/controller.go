@@ -8,47 +8,128 @@ import (
 	"golang-http-service/pkg/business/control"
 	"golang-http-service/pkg/business/entity"
 	"golang-http-service/pkg/integration"
+	"golang-http-service/pkg/integration/logctx"
 )
 
 type controller struct {
 	userRepo control.UserRepo
+	metrics  control.Metrics
 }
 
-func NewController(userRepo control.UserRepo) api.StrictServerInterface {
+func NewController(userRepo control.UserRepo, metrics control.Metrics) api.StrictServerInterface {
 	return &controller{
 		userRepo: userRepo,
+		metrics:  metrics,
 	}
 }
 
 func (c *controller) CreateUser(ctx context.Context, request api.CreateUserRequestObject) (api.CreateUserResponseObject, error) {
+	// generated transport object carries `validate` tags of the spec, they are checked even without openapi middleware
+	if err := control.Validate(request.Body); err != nil {
+		p := integration.BadRequestError(ctx, problemOf(err))
+		return api.CreateUser400ApplicationProblemPlusJSONResponse{BadRequestApplicationProblemPlusJSONResponse: p}, nil
+	}
 	u := entity.User{
 		Name: request.Body.Name,
 	}
-	if err := c.userRepo.CreateUser(ctx, u); err != nil {
+	created, err := c.userRepo.CreateUser(ctx, u)
+	if err != nil {
 		if control.IsValidationError(err) {
-			p := integration.BadRequestError(ctx, err)
+			p := integration.BadRequestError(ctx, problemOf(err))
 			return api.CreateUser400ApplicationProblemPlusJSONResponse{BadRequestApplicationProblemPlusJSONResponse: p}, nil
 		}
 		return nil, fmt.Errorf("failed to create users; %w", err)
 	}
-	return api.CreateUser201Response{}, nil
+	logctx.From(ctx).InfoContext(ctx, "user is registered", "userId", created.Id)
+	return api.CreateUser201JSONResponse{
+		Body: api.UserV1{
+			Id:   created.Id,
+			Name: created.Name,
+		},
+		Headers: api.CreateUser201ResponseHeaders{
+			Location:     integration.Location(ctx, fmt.Sprintf("/users/v1/%d", created.Id)),
+			ETag:         integration.ETag(created.Version),
+			LastModified: integration.LastModified(created.UpdatedAt),
+		},
+	}, nil
 }
 
 func (c *controller) GetUser(ctx context.Context, request api.GetUserRequestObject) (api.GetUserResponseObject, error) {
 	if u, err := c.userRepo.FindUser(ctx, request.Userid); err != nil {
 		if control.IsMissingEntityError(err) {
-			p := integration.NotFoundError(ctx, err)
+			c.metrics.UserLookupMissed(ctx)
+			p := integration.NotFoundError(ctx, problemOf(err))
 			return api.GetUser404ApplicationProblemPlusJSONResponse{NotFoundApplicationProblemPlusJSONResponse: p}, nil
 		}
 		return nil, fmt.Errorf("failed to create users; %w", err)
 	} else {
+		etag := integration.ETag(u.Version)
+		lastModified := integration.LastModified(u.UpdatedAt)
+		if integration.IfNoneMatch(request.Params.IfNoneMatch, etag) {
+			return api.GetUser304Response{Headers: api.GetUser304ResponseHeaders{ETag: etag, LastModified: lastModified}}, nil
+		}
 		return api.GetUser200JSONResponse{
-			Id:   u.Id,
-			Name: u.Name,
+			Body: api.UserV1{
+				Id:   u.Id,
+				Name: u.Name,
+			},
+			Headers: api.GetUser200ResponseHeaders{ETag: etag, LastModified: lastModified},
 		}, nil
 	}
 }
 
+func (c *controller) UpdateUser(ctx context.Context, request api.UpdateUserRequestObject) (api.UpdateUserResponseObject, error) {
+	if err := control.Validate(request.Body); err != nil {
+		p := integration.BadRequestError(ctx, problemOf(err))
+		return api.UpdateUser400ApplicationProblemPlusJSONResponse{BadRequestApplicationProblemPlusJSONResponse: p}, nil
+	}
+	current, err := c.userRepo.FindUser(ctx, request.Userid)
+	if err != nil {
+		if control.IsMissingEntityError(err) {
+			p := integration.NotFoundError(ctx, problemOf(err))
+			return api.UpdateUser404ApplicationProblemPlusJSONResponse{NotFoundApplicationProblemPlusJSONResponse: p}, nil
+		}
+		return nil, fmt.Errorf("failed to find user; %w", err)
+	}
+	if !integration.IfMatch(request.Params.IfMatch, integration.ETag(current.Version)) {
+		err := control.NewVersionConflictError(fmt.Sprintf("user with id %d has version %d", current.Id, current.Version))
+		p := integration.PreconditionFailedError(ctx, problemOf(err))
+		return api.UpdateUser412ApplicationProblemPlusJSONResponse{PreconditionFailedApplicationProblemPlusJSONResponse: p}, nil
+	}
+	// the repo compares versions again, so a concurrent change between find and update is rejected as well
+	u := entity.User{
+		Id:      request.Userid,
+		Name:    request.Body.Name,
+		Version: current.Version,
+	}
+	updated, err := c.userRepo.UpdateUser(ctx, u)
+	if err != nil {
+		switch {
+		case control.IsValidationError(err):
+			p := integration.BadRequestError(ctx, problemOf(err))
+			return api.UpdateUser400ApplicationProblemPlusJSONResponse{BadRequestApplicationProblemPlusJSONResponse: p}, nil
+		case control.IsMissingEntityError(err):
+			p := integration.NotFoundError(ctx, problemOf(err))
+			return api.UpdateUser404ApplicationProblemPlusJSONResponse{NotFoundApplicationProblemPlusJSONResponse: p}, nil
+		case control.IsVersionConflictError(err):
+			p := integration.PreconditionFailedError(ctx, problemOf(err))
+			return api.UpdateUser412ApplicationProblemPlusJSONResponse{PreconditionFailedApplicationProblemPlusJSONResponse: p}, nil
+		}
+		return nil, fmt.Errorf("failed to update user; %w", err)
+	}
+	logctx.From(ctx).InfoContext(ctx, "user is updated", "userId", updated.Id, "version", updated.Version)
+	return api.UpdateUser200JSONResponse{
+		Body: api.UserV1{
+			Id:   updated.Id,
+			Name: updated.Name,
+		},
+		Headers: api.UpdateUser200ResponseHeaders{
+			ETag:         integration.ETag(updated.Version),
+			LastModified: integration.LastModified(updated.UpdatedAt),
+		},
+	}, nil
+}
+
 func (c *controller) GetUsers(ctx context.Context, _ api.GetUsersRequestObject) (api.GetUsersResponseObject, error) {
 	users := c.userRepo.FindAllUsers(ctx)
 	res := make(api.GetUsers200JSONResponse, len(users))
@@ -2,12 +2,19 @@ package boundary
 
 import (
 	"context"
+	"encoding/json"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 	"golang-http-service/api"
+	"golang-http-service/pkg/business/control"
 	controlmock "golang-http-service/pkg/business/control/mock"
 	"golang-http-service/pkg/business/entity"
+	"golang-http-service/pkg/integration"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestController_Should_Create_User(t *testing.T) {
@@ -15,12 +22,103 @@ func TestController_Should_Create_User(t *testing.T) {
 	defer ctrl.Finish()
 
 	repo := controlmock.NewMockUserRepo(ctrl)
-	c := NewController(repo)
+	c := NewController(repo, control.NewNoopMetrics())
 	ctx := context.Background()
 	name := "some"
-	repo.EXPECT().CreateUser(ctx, entity.User{Name: name})
+	repo.EXPECT().CreateUser(ctx, entity.User{Name: name}).Return(entity.User{Id: 7, Name: name, Version: 1, UpdatedAt: time.Now()}, nil)
 
-	_, err := c.CreateUser(ctx, api.CreateUserRequestObject{Body: &api.UserV1{Name: name}})
+	res, err := c.CreateUser(ctx, api.CreateUserRequestObject{Body: &api.UserV1{Name: name}})
 
 	assert.NoError(t, err)
+	created := res.(api.CreateUser201JSONResponse)
+	assert.Equal(t, api.UserV1{Id: 7, Name: name}, created.Body)
+	assert.Equal(t, "/users/v1/7", created.Headers.Location)
+	assert.Equal(t, `"1"`, created.Headers.ETag)
+}
+
+func TestController_Should_Count_Missed_Lookups(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := controlmock.NewMockUserRepo(ctrl)
+	metrics := control.NewMetricsRecorder()
+	c := NewController(repo, metrics)
+	ctx := context.Background()
+	repo.EXPECT().FindUser(ctx, int32(1)).Return(entity.User{}, control.NewMissingEntityError("not found"))
+
+	res, err := c.GetUser(ctx, api.GetUserRequestObject{Userid: 1})
+
+	assert.NoError(t, err)
+	assert.IsType(t, api.GetUser404ApplicationProblemPlusJSONResponse{}, res)
+	assert.Equal(t, 1, metrics.Value(control.MetricUsersLookupMisses))
+}
+
+func TestController_Should_Reject_Invalid_User_Without_Calling_Repo(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := controlmock.NewMockUserRepo(ctrl)
+	c := NewController(repo, control.NewNoopMetrics())
+
+	res, err := c.CreateUser(context.Background(), api.CreateUserRequestObject{Body: &api.UserV1{Id: -1, Name: "some"}})
+
+	assert.NoError(t, err)
+	assert.IsType(t, api.CreateUser400ApplicationProblemPlusJSONResponse{}, res)
+}
+
+func TestController_Should_Return_Not_Modified_For_Current_ETag(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := controlmock.NewMockUserRepo(ctrl)
+	c := NewController(repo, control.NewNoopMetrics())
+	ctx := context.Background()
+	repo.EXPECT().FindUser(ctx, int32(1)).Return(entity.User{Id: 1, Name: "some", Version: 3, UpdatedAt: time.Now()}, nil).Times(2)
+
+	res, err := c.GetUser(ctx, api.GetUserRequestObject{Userid: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, `"3"`, res.(api.GetUser200JSONResponse).Headers.ETag)
+
+	etag := `"3"`
+	res, err = c.GetUser(ctx, api.GetUserRequestObject{Userid: 1, Params: api.GetUserParams{IfNoneMatch: &etag}})
+	assert.NoError(t, err)
+	assert.IsType(t, api.GetUser304Response{}, res)
+}
+
+func TestController_Should_Reject_Update_Of_Stale_Version(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := controlmock.NewMockUserRepo(ctrl)
+	c := NewController(repo, control.NewNoopMetrics())
+	ctx := context.Background()
+	repo.EXPECT().FindUser(ctx, int32(1)).Return(entity.User{Id: 1, Name: "some", Version: 3}, nil)
+	etag := `"2"`
+
+	res, err := c.UpdateUser(ctx, api.UpdateUserRequestObject{Userid: 1, Params: api.UpdateUserParams{IfMatch: &etag}, Body: &api.UserV1{Name: "other"}})
+
+	assert.NoError(t, err)
+	assert.IsType(t, api.UpdateUser412ApplicationProblemPlusJSONResponse{}, res)
+}
+
+func TestController_Should_Serialize_Precondition_Failed_Problem(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := controlmock.NewMockUserRepo(ctrl)
+	h := api.Handler(api.NewStrictHandler(NewController(repo, control.NewNoopMetrics()), nil))
+	repo.EXPECT().FindUser(gomock.Any(), int32(1)).Return(entity.User{Id: 1, Name: "some", Version: 3}, nil)
+	r := httptest.NewRequest(http.MethodPut, "/users/v1/1", strings.NewReader(`{"id": 1, "name": "other"}`))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("If-Match", `"2"`)
+	rec := httptest.NewRecorder()
+
+	h.ServeHTTP(rec, r)
+
+	assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+	var p api.ProblemDetail
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &p))
+	assert.Equal(t, integration.VersionMismatchProblem.Type, p.Type)
+	assert.Equal(t, http.StatusPreconditionFailed, p.Status)
+	assert.EqualError(t, p.Detail, "user with id 1 has version 3")
 }
@@ -0,0 +1,26 @@
+package boundary
+
+import (
+	"errors"
+
+	"golang-http-service/pkg/business/control"
+	"golang-http-service/pkg/integration"
+)
+
+// problemOf maps domain errors to problem types of the api catalog, other errors are returned as is
+func problemOf(err error) error {
+	var validationError *control.ValidationError
+	switch {
+	case errors.As(err, &validationError):
+		fields := make([]integration.FieldError, len(validationError.Fields()))
+		for i, f := range validationError.Fields() {
+			fields[i] = integration.FieldError{Field: f.Field, Message: f.Message}
+		}
+		return integration.NewProblem(integration.ValidationProblem, err, fields...)
+	case control.IsMissingEntityError(err):
+		return integration.NewProblem(integration.MissingEntityProblem, err)
+	case control.IsVersionConflictError(err):
+		return integration.NewProblem(integration.VersionMismatchProblem, err)
+	}
+	return err
+}
@@ -0,0 +1,35 @@
+package boundary
+
+import (
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"golang-http-service/pkg/business/control"
+	"golang-http-service/pkg/integration"
+	"testing"
+)
+
+func TestProblemOf_Should_Map_Domain_Errors_To_Problem_Types(t *testing.T) {
+	validationError := control.NewValidationError("user with name bob already exists", control.FieldError{Field: "name", Message: "is already taken"})
+	tests := map[string]struct {
+		err         error
+		problemType integration.ProblemType
+	}{
+		"validation":       {err: validationError, problemType: integration.ValidationProblem},
+		"missing entity":   {err: fmt.Errorf("failed to find user; %w", control.NewMissingEntityError("user 1 not found")), problemType: integration.MissingEntityProblem},
+		"version conflict": {err: control.NewVersionConflictError("user 1 has version 3"), problemType: integration.VersionMismatchProblem},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var problem *integration.Problem
+			assert.True(t, errors.As(problemOf(test.err), &problem))
+			assert.Equal(t, test.problemType, problem.Type)
+			assert.ErrorIs(t, problem, test.err)
+		})
+	}
+
+	var problem *integration.Problem
+	assert.True(t, errors.As(problemOf(validationError), &problem))
+	assert.Equal(t, []integration.FieldError{{Field: "name", Message: "is already taken"}}, problem.Fields)
+	assert.False(t, errors.As(problemOf(errors.New("boom")), &problem))
+}
@@ -4,16 +4,28 @@ import (
 	"errors"
 )
 
+// FieldError describes why value of a particular input field is invalid
+type FieldError struct {
+	Field   string
+	Message string
+}
+
 type ValidationError struct {
-	err string
+	err    string
+	fields []FieldError
 }
 
 func (e *ValidationError) Error() string {
 	return e.err
 }
 
-func NewValidationError(err string) *ValidationError {
-	return &ValidationError{err: err}
+// Fields returns per field messages, empty if the error is not related to particular fields
+func (e *ValidationError) Fields() []FieldError {
+	return e.fields
+}
+
+func NewValidationError(err string, fields ...FieldError) *ValidationError {
+	return &ValidationError{err: err, fields: fields}
 }
 
 func IsValidationError(err error) bool {
@@ -39,3 +51,22 @@ func IsMissingEntityError(err error) bool {
 	ok := errors.As(err, &missingEntityError)
 	return ok
 }
+
+// VersionConflictError is returned when the entity was changed since the version the update is based on
+type VersionConflictError struct {
+	err string
+}
+
+func (e *VersionConflictError) Error() string {
+	return e.err
+}
+
+func NewVersionConflictError(err string) *VersionConflictError {
+	return &VersionConflictError{err: err}
+}
+
+func IsVersionConflictError(err error) bool {
+	var versionConflictError *VersionConflictError
+	ok := errors.As(err, &versionConflictError)
+	return ok
+}
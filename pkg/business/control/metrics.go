@@ -0,0 +1,74 @@
+package control
+
+import (
+	"context"
+	"sync"
+)
+
+// metric names shared by Metrics implementations
+const (
+	MetricUsersCreated                 = "users_created"
+	MetricUsersDuplicateNameRejections = "users_duplicate_name_rejections"
+	MetricUsersLookupMisses            = "users_lookup_misses"
+	MetricUsers                        = "users"
+)
+
+// Metrics is domain metrics facade; implementation is injected by the app, so business code does not depend on telemetry sdk
+type Metrics interface {
+	UserCreated(ctx context.Context)
+	DuplicateNameRejected(ctx context.Context)
+	UserLookupMissed(ctx context.Context)
+	UserCount(ctx context.Context, count int)
+}
+
+type noopMetrics struct{}
+
+func NewNoopMetrics() Metrics {
+	return noopMetrics{}
+}
+
+func (noopMetrics) UserCreated(context.Context)           {}
+func (noopMetrics) DuplicateNameRejected(context.Context) {}
+func (noopMetrics) UserLookupMissed(context.Context)      {}
+func (noopMetrics) UserCount(context.Context, int)        {}
+
+// MetricsRecorder keeps emitted metrics in memory so tests can assert on them
+type MetricsRecorder struct {
+	mu     sync.Mutex
+	values map[string]int
+}
+
+func NewMetricsRecorder() *MetricsRecorder {
+	return &MetricsRecorder{values: make(map[string]int)}
+}
+
+func (r *MetricsRecorder) UserCreated(context.Context) {
+	r.add(MetricUsersCreated, 1)
+}
+
+func (r *MetricsRecorder) DuplicateNameRejected(context.Context) {
+	r.add(MetricUsersDuplicateNameRejections, 1)
+}
+
+func (r *MetricsRecorder) UserLookupMissed(context.Context) {
+	r.add(MetricUsersLookupMisses, 1)
+}
+
+func (r *MetricsRecorder) UserCount(_ context.Context, count int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.values[MetricUsers] = count
+}
+
+func (r *MetricsRecorder) add(name string, delta int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.values[name] += delta
+}
+
+// Value returns counter sum or the last gauge value
+func (r *MetricsRecorder) Value(name string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.values[name]
+}
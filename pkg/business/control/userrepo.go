@@ -4,39 +4,99 @@ import (
 	"context"
 	"fmt"
 	"math/rand"
+	"sync"
+	"time"
 
 	"golang-http-service/pkg/business/entity"
+	"golang-http-service/pkg/integration/logctx"
 )
 
 type userRepo struct {
-	db map[int32]entity.User
+	mu      sync.RWMutex
+	db      map[int32]entity.User
+	metrics Metrics
 }
 
 type UserRepo interface {
-	CreateUser(ctx context.Context, u entity.User) error
+	// CreateUser stores a new user, the user with assigned id and version is returned
+	CreateUser(ctx context.Context, u entity.User) (entity.User, error)
+	// UpdateUser replaces name of the user; non-zero version of u must be the current one, the updated user is returned
+	UpdateUser(ctx context.Context, u entity.User) (entity.User, error)
 	FindUser(ctx context.Context, id int32) (entity.User, error)
 	FindAllUsers(ctx context.Context) []entity.User
 }
 
-func NewUserRepo() UserRepo {
+func NewUserRepo(metrics Metrics) UserRepo {
 	return &userRepo{
-		db: make(map[int32]entity.User),
+		db:      make(map[int32]entity.User),
+		metrics: metrics,
 	}
 }
 
-func (r *userRepo) CreateUser(_ context.Context, u entity.User) error {
-	for i := range r.db {
-		if r.db[i].Name == u.Name {
-			return NewValidationError(fmt.Sprintf("user with name %s already exists", u.Name))
-		}
+func (r *userRepo) CreateUser(ctx context.Context, u entity.User) (entity.User, error) {
+	if err := Validate(u); err != nil {
+		return entity.User{}, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// ids are not negative, so the name is checked against all users
+	if err := r.checkNameIsFree(ctx, u.Name, -1); err != nil {
+		return entity.User{}, err
 	}
 	id := int32(rand.Intn(999))
 	u.Id = id
+	u.Version = 1
+	u.UpdatedAt = time.Now()
 	r.db[id] = u
+	logctx.From(ctx).DebugContext(ctx, "user created", "userId", id)
+	r.metrics.UserCreated(ctx)
+	r.metrics.UserCount(ctx, len(r.db))
+	return u, nil
+}
+
+func (r *userRepo) UpdateUser(ctx context.Context, u entity.User) (entity.User, error) {
+	if err := Validate(u); err != nil {
+		return entity.User{}, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	current, ok := r.db[u.Id]
+	if !ok {
+		return entity.User{}, NewMissingEntityError(fmt.Sprintf("user with id %d is not found", u.Id))
+	}
+	if u.Version != 0 && u.Version != current.Version {
+		return entity.User{}, NewVersionConflictError(fmt.Sprintf("user with id %d has version %d, not %d", u.Id, current.Version, u.Version))
+	}
+	if err := r.checkNameIsFree(ctx, u.Name, u.Id); err != nil {
+		return entity.User{}, err
+	}
+	if current.Name == u.Name {
+		return current, nil
+	}
+	current.Name = u.Name
+	current.Version++
+	current.UpdatedAt = time.Now()
+	r.db[u.Id] = current
+	logctx.From(ctx).DebugContext(ctx, "user updated", "userId", u.Id, "version", current.Version)
+	return current, nil
+}
+
+// checkNameIsFree fails if the name is taken by a user other than exceptID, callers hold the lock
+func (r *userRepo) checkNameIsFree(ctx context.Context, name string, exceptID int32) error {
+	for id := range r.db {
+		if id != exceptID && r.db[id].Name == name {
+			r.metrics.DuplicateNameRejected(ctx)
+			logctx.From(ctx).DebugContext(ctx, "user name is already taken", "name", name)
+			return NewValidationError(fmt.Sprintf("user with name %s already exists", name),
+				FieldError{Field: "name", Message: "is already taken"})
+		}
+	}
 	return nil
 }
 
 func (r *userRepo) FindUser(_ context.Context, id int32) (entity.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 	if u, ok := r.db[id]; ok {
 		return u, nil
 	}
@@ -44,6 +104,8 @@ func (r *userRepo) FindUser(_ context.Context, id int32) (entity.User, error) {
 }
 
 func (r *userRepo) FindAllUsers(_ context.Context) []entity.User {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 	users := make([]entity.User, 0, len(r.db))
 	for _, u := range r.db {
 		users = append(users, u)
@@ -9,35 +9,44 @@ import (
 )
 
 func TestUserRepo_Should_Create_User(t *testing.T) {
-	r := NewUserRepo()
+	metrics := NewMetricsRecorder()
+	r := NewUserRepo(metrics)
 	ctx := context.Background()
 	name := "some"
 
-	err := r.CreateUser(ctx, entity.User{Name: name})
+	created, err := r.CreateUser(ctx, entity.User{Name: name})
 	assert.NoError(t, err)
+	assert.Equal(t, name, created.Name)
+	assert.Equal(t, int64(1), created.Version)
 
 	us := r.FindAllUsers(ctx)
 	assert.Len(t, us, 1)
 	assert.Equal(t, us[0].Name, name)
-	assert.NotZero(t, us[0].Id)
+	assert.Equal(t, created.Id, us[0].Id)
+	assert.Equal(t, 1, metrics.Value(MetricUsersCreated))
+	assert.Equal(t, 1, metrics.Value(MetricUsers))
 }
 
 func TestUserRepo_Should_Not_Create_User_With_Same_Name(t *testing.T) {
-	r := NewUserRepo()
+	metrics := NewMetricsRecorder()
+	r := NewUserRepo(metrics)
 	ctx := context.Background()
 	name := "some"
-	_ = r.CreateUser(ctx, entity.User{Name: name})
+	_, _ = r.CreateUser(ctx, entity.User{Name: name})
 
-	err := r.CreateUser(ctx, entity.User{Name: name})
+	_, err := r.CreateUser(ctx, entity.User{Name: name})
 	var expected *ValidationError
 	assert.ErrorAs(t, err, &expected)
+	assert.Equal(t, []FieldError{{Field: "name", Message: "is already taken"}}, expected.Fields())
+	assert.Equal(t, 1, metrics.Value(MetricUsersDuplicateNameRejections))
+	assert.Equal(t, 1, metrics.Value(MetricUsersCreated))
 }
 
 func TestUserRepo_Should_Find_User(t *testing.T) {
-	r := NewUserRepo()
+	r := NewUserRepo(NewNoopMetrics())
 	ctx := context.Background()
 	name := "some"
-	_ = r.CreateUser(ctx, entity.User{Name: name})
+	_, _ = r.CreateUser(ctx, entity.User{Name: name})
 
 	us := r.FindAllUsers(ctx)
 	assert.Len(t, us, 1)
@@ -49,10 +58,43 @@ func TestUserRepo_Should_Find_User(t *testing.T) {
 }
 
 func TestUserRepo_Should_Fail_To_Find_Absent_User(t *testing.T) {
-	r := NewUserRepo()
+	r := NewUserRepo(NewNoopMetrics())
 	ctx := context.Background()
 
 	_, err := r.FindUser(ctx, 1)
 	var expected *MissingEntityError
 	assert.ErrorAs(t, err, &expected)
 }
+
+func TestUserRepo_Should_Update_User_Of_Current_Version(t *testing.T) {
+	r := NewUserRepo(NewNoopMetrics())
+	ctx := context.Background()
+	created, _ := r.CreateUser(ctx, entity.User{Name: "some"})
+	assert.Equal(t, int64(1), created.Version)
+	assert.False(t, created.UpdatedAt.IsZero())
+
+	updated, err := r.UpdateUser(ctx, entity.User{Id: created.Id, Name: "other", Version: created.Version})
+	assert.NoError(t, err)
+	assert.Equal(t, "other", updated.Name)
+	assert.Equal(t, int64(2), updated.Version)
+
+	_, err = r.UpdateUser(ctx, entity.User{Id: created.Id, Name: "another", Version: created.Version})
+	var expected *VersionConflictError
+	assert.ErrorAs(t, err, &expected)
+	u, _ := r.FindUser(ctx, created.Id)
+	assert.Equal(t, "other", u.Name)
+}
+
+func TestUserRepo_Should_Not_Update_User_To_Taken_Name(t *testing.T) {
+	r := NewUserRepo(NewNoopMetrics())
+	ctx := context.Background()
+	_, _ = r.CreateUser(ctx, entity.User{Name: "some"})
+	u, _ := r.CreateUser(ctx, entity.User{Name: "other"})
+
+	_, err := r.UpdateUser(ctx, entity.User{Id: u.Id, Name: "some"})
+	assert.True(t, IsValidationError(err))
+
+	same, err := r.UpdateUser(ctx, entity.User{Id: u.Id, Name: "other"})
+	assert.NoError(t, err)
+	assert.Equal(t, u.Version, same.Version)
+}
@@ -0,0 +1,76 @@
+package control
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"golang-http-service/pkg/business/entity"
+)
+
+type tracingUserRepo struct {
+	next   UserRepo
+	tracer trace.Tracer
+}
+
+// NewTracingUserRepo decorates repo with child spans per call; errors are classified with error.type attribute
+func NewTracingUserRepo(next UserRepo, tracer trace.Tracer) UserRepo {
+	return &tracingUserRepo{
+		next:   next,
+		tracer: tracer,
+	}
+}
+
+func (r *tracingUserRepo) CreateUser(ctx context.Context, u entity.User) (entity.User, error) {
+	ctx, span := r.tracer.Start(ctx, "UserRepo.CreateUser")
+	defer span.End()
+	created, err := r.next.CreateUser(ctx, u)
+	if err == nil {
+		span.SetAttributes(attribute.Int("user.id", int(created.Id)))
+	}
+	recordRepoError(span, err)
+	return created, err
+}
+
+func (r *tracingUserRepo) UpdateUser(ctx context.Context, u entity.User) (entity.User, error) {
+	ctx, span := r.tracer.Start(ctx, "UserRepo.UpdateUser", trace.WithAttributes(attribute.Int("user.id", int(u.Id))))
+	defer span.End()
+	updated, err := r.next.UpdateUser(ctx, u)
+	recordRepoError(span, err)
+	return updated, err
+}
+
+func (r *tracingUserRepo) FindUser(ctx context.Context, id int32) (entity.User, error) {
+	ctx, span := r.tracer.Start(ctx, "UserRepo.FindUser", trace.WithAttributes(attribute.Int("user.id", int(id))))
+	defer span.End()
+	u, err := r.next.FindUser(ctx, id)
+	recordRepoError(span, err)
+	return u, err
+}
+
+func (r *tracingUserRepo) FindAllUsers(ctx context.Context) []entity.User {
+	ctx, span := r.tracer.Start(ctx, "UserRepo.FindAllUsers")
+	defer span.End()
+	users := r.next.FindAllUsers(ctx)
+	span.SetAttributes(attribute.Int("users.count", len(users)))
+	return users
+}
+
+func recordRepoError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+	errorType := "internal"
+	switch {
+	case IsValidationError(err):
+		errorType = "validation"
+	case IsMissingEntityError(err):
+		errorType = "missing_entity"
+	case IsVersionConflictError(err):
+		errorType = "version_conflict"
+	}
+	span.SetAttributes(attribute.String("error.type", errorType))
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
@@ -0,0 +1,36 @@
+package control
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"golang-http-service/pkg/business/entity"
+)
+
+func TestTracingUserRepo_Should_Record_Spans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := trace.NewTracerProvider(trace.WithSpanProcessor(recorder))
+	r := NewTracingUserRepo(NewUserRepo(NewNoopMetrics()), tp.Tracer("test"))
+	ctx := context.Background()
+
+	created, err := r.CreateUser(ctx, entity.User{Name: "some"})
+	require.NoError(t, err)
+	_, err = r.FindUser(ctx, 1000)
+	require.Error(t, err)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	assert.Equal(t, "UserRepo.CreateUser", spans[0].Name())
+	assert.Equal(t, []attribute.KeyValue{attribute.Int("user.id", int(created.Id))}, spans[0].Attributes())
+	assert.Equal(t, codes.Unset, spans[0].Status().Code)
+	assert.Equal(t, "UserRepo.FindUser", spans[1].Name())
+	assert.Contains(t, spans[1].Attributes(), attribute.Int("user.id", 1000))
+	assert.Contains(t, spans[1].Attributes(), attribute.String("error.type", "missing_entity"))
+	assert.Equal(t, codes.Error, spans[1].Status().Code)
+}
@@ -0,0 +1,92 @@
+package control
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// reservedUserNames cannot be taken by users, compared case-insensitively
+var reservedUserNames = []string{"admin", "administrator", "root", "system", "support", "anonymous"}
+
+var userNameRe = regexp.MustCompile(`^[\p{L}\p{M}][\p{L}\p{M} '.-]*$`)
+
+var validate = sync.OnceValue(func() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+	v.RegisterTagNameFunc(fieldName)
+	// errors are impossible here: tags are not empty and functions are not nil
+	_ = v.RegisterValidation("username", func(fl validator.FieldLevel) bool {
+		return userNameRe.MatchString(fl.Field().String())
+	})
+	_ = v.RegisterValidation("notreserved", func(fl validator.FieldLevel) bool {
+		name := strings.TrimSpace(fl.Field().String())
+		for _, reserved := range reservedUserNames {
+			if strings.EqualFold(name, reserved) {
+				return false
+			}
+		}
+		return true
+	})
+	return v
+})
+
+// Validate checks `validate` struct tags of entities and transport objects;
+// violations are returned as ValidationError with per field messages
+func Validate(s any) error {
+	err := validate().Struct(s)
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return err
+	}
+	fields := make([]FieldError, len(validationErrors))
+	messages := make([]string, len(validationErrors))
+	for i, fe := range validationErrors {
+		fields[i] = FieldError{Field: fieldPath(fe), Message: fieldMessage(fe)}
+		messages[i] = fields[i].Field + " " + fields[i].Message
+	}
+	return NewValidationError(strings.Join(messages, "; "), fields...)
+}
+
+// fieldName is json name of the field when it is set, so field errors point to the request body fields
+func fieldName(f reflect.StructField) string {
+	if name, _, _ := strings.Cut(f.Tag.Get("json"), ","); name != "" && name != "-" {
+		return name
+	}
+	runes := []rune(f.Name)
+	runes[0] = unicode.ToLower(runes[0])
+	return string(runes)
+}
+
+// fieldPath is dotted path of the field without the top level struct name, e.g. `address.city`
+func fieldPath(fe validator.FieldError) string {
+	_, path, _ := strings.Cut(fe.Namespace(), ".")
+	return path
+}
+
+func fieldMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "min":
+		if fe.Kind() == reflect.String {
+			return fmt.Sprintf("must be at least %s characters long", fe.Param())
+		}
+		return fmt.Sprintf("must be at least %s", fe.Param())
+	case "max":
+		if fe.Kind() == reflect.String {
+			return fmt.Sprintf("must be at most %s characters long", fe.Param())
+		}
+		return fmt.Sprintf("must be at most %s", fe.Param())
+	case "username":
+		return "must start with a letter and contain only letters, spaces, apostrophes, dots and hyphens"
+	case "notreserved":
+		return "is reserved"
+	}
+	return fmt.Sprintf("does not satisfy %s rule", fe.Tag())
+}
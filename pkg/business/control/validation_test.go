@@ -0,0 +1,51 @@
+package control
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang-http-service/pkg/business/entity"
+)
+
+func TestValidate_Should_Accept_Valid_User(t *testing.T) {
+	assert.NoError(t, Validate(entity.User{Name: "Mary-Jane O'Neil Jr."}))
+}
+
+func TestValidate_Should_Return_Field_Errors(t *testing.T) {
+	tests := map[string]struct {
+		name    string
+		message string
+	}{
+		"empty":    {name: "", message: "is required"},
+		"too long": {name: strings.Repeat("a", 65), message: "must be at most 64 characters long"},
+		"charset":  {name: "bob<script>", message: "must start with a letter and contain only letters, spaces, apostrophes, dots and hyphens"},
+		"reserved": {name: "Admin", message: "is reserved"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := Validate(entity.User{Name: tt.name})
+
+			var validationError *ValidationError
+			require.ErrorAs(t, err, &validationError)
+			assert.Equal(t, []FieldError{{Field: "name", Message: tt.message}}, validationError.Fields())
+		})
+	}
+}
+
+func TestValidate_Should_Use_Json_Field_Names(t *testing.T) {
+	type address struct {
+		ZipCode string `json:"zip_code" validate:"max=5"`
+	}
+	type request struct {
+		Address address `json:"address"`
+	}
+
+	err := Validate(request{Address: address{ZipCode: "1234567"}})
+
+	var validationError *ValidationError
+	require.ErrorAs(t, err, &validationError)
+	assert.Equal(t, []FieldError{{Field: "address.zip_code", Message: "must be at most 5 characters long"}}, validationError.Fields())
+	assert.Equal(t, "address.zip_code must be at most 5 characters long", err.Error())
+}
@@ -1,6 +1,10 @@
 package entity
 
+import "time"
+
 type User struct {
-	Id   int32
-	Name string
+	Id        int32
+	Name      string    `validate:"required,min=1,max=64,username,notreserved"`
+	Version   int64     // incremented on every change, zero for users that are not stored yet
+	UpdatedAt time.Time // time of the last change
 }
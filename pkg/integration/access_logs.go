@@ -0,0 +1,172 @@
+package integration
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/felixge/httpsnoop"
+	"go.opentelemetry.io/otel/sdk/trace"
+	oteltrace "go.opentelemetry.io/otel/trace"
+	"golang-http-service/pkg/integration/logctx"
+
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+const redactedValue = "***"
+
+type accessLogger struct {
+	cfg           AccessLogConfig
+	redactHeaders map[string]bool
+	redactParams  map[string]bool
+	mu            sync.Mutex // guards out
+	out           io.Writer
+}
+
+func newAccessLogger(cfg AccessLogConfig, out io.Writer) (*accessLogger, error) {
+	switch cfg.Format {
+	case "", "json", "combined":
+	default:
+		return nil, fmt.Errorf("unknown access log format %s", cfg.Format)
+	}
+	for _, p := range cfg.ExcludePaths {
+		if _, err := path.Match(p, ""); err != nil {
+			return nil, fmt.Errorf("invalid access log exclude path %s: %w", p, err)
+		}
+	}
+	l := &accessLogger{
+		cfg:           cfg,
+		redactHeaders: make(map[string]bool),
+		redactParams:  make(map[string]bool),
+		out:           out,
+	}
+	for _, h := range cfg.RedactHeaders {
+		l.redactHeaders[http.CanonicalHeaderKey(h)] = true
+	}
+	for _, p := range cfg.RedactQueryParams {
+		l.redactParams[strings.ToLower(p)] = true
+	}
+	return l, nil
+}
+
+func (l *accessLogger) log(r *http.Request, m httpsnoop.Metrics, bytesIn int64) {
+	if !l.shouldLog(r, m.Code) {
+		return
+	}
+	if l.cfg.Format == "combined" {
+		l.writeCombined(r, m)
+		return
+	}
+
+	attrs := []any{
+		slog.String("host", r.Host),
+		slog.String("uri", l.uri(r)),
+		slog.String("method", r.Method),
+		slog.String("referer", r.Referer()),
+		slog.Int("status", m.Code),
+		slog.Int64("bytesIn", bytesIn),
+		slog.Int64("bytesOut", m.Written),
+		slog.Duration("latency", m.Duration),
+	}
+	if len(l.cfg.Headers) > 0 {
+		var headerAttrs []any
+		for _, name := range l.cfg.Headers {
+			if v := r.Header.Get(name); v != "" {
+				if l.redactHeaders[http.CanonicalHeaderKey(name)] {
+					v = redactedValue
+				}
+				headerAttrs = append(headerAttrs, slog.String(name, v))
+			}
+		}
+		attrs = append(attrs, slog.Group("headers", headerAttrs...))
+	}
+
+	span := oteltrace.SpanFromContext(r.Context())
+	if readSpan, ok := span.(trace.ReadOnlySpan); ok {
+		for _, event := range readSpan.Events() {
+			if event.Name == semconv.ExceptionEventName {
+				var errAttrs []any
+				for _, a := range event.Attributes {
+					errAttrs = append(errAttrs, slog.String(string(a.Key), a.Value.AsString()))
+				}
+				attrs = append(attrs, slog.Group("err", errAttrs...))
+				break
+			}
+		}
+	}
+
+	logctx.With(r.Context(), NamedLogger("http.access")).InfoContext(r.Context(), "access", attrs...)
+}
+
+func (l *accessLogger) shouldLog(r *http.Request, status int) bool {
+	if slices.Contains(l.cfg.ExcludeStatuses, status) {
+		return false
+	}
+	for _, p := range l.cfg.ExcludePaths {
+		if ok, _ := path.Match(p, r.URL.Path); ok {
+			return false
+		}
+	}
+	if status < http.StatusBadRequest && l.cfg.SuccessSampleRatio < 1 {
+		return rand.Float64() < l.cfg.SuccessSampleRatio
+	}
+	return true
+}
+
+// uri is request path with query params when they are enabled, values of sensitive params are masked
+func (l *accessLogger) uri(r *http.Request) string {
+	if !l.cfg.Query || r.URL.RawQuery == "" {
+		return r.URL.EscapedPath()
+	}
+	query := r.URL.Query()
+	for name, values := range query {
+		if l.redactParams[strings.ToLower(name)] {
+			for i := range values {
+				values[i] = redactedValue
+			}
+		}
+	}
+	return r.URL.EscapedPath() + "?" + query.Encode()
+}
+
+// writeCombined writes NCSA combined log format line:
+// host ident authuser [date] "request line" status bytes "referer" "user-agent"
+func (l *accessLogger) writeCombined(r *http.Request, m httpsnoop.Metrics) {
+	host := remoteHost(r)
+	user := "-"
+	if subject, ok := logctx.Lookup(r.Context(), "subject"); ok && subject.String() != "" {
+		user = subject.String()
+	}
+	size := "-"
+	if m.Written > 0 {
+		size = strconv.FormatInt(m.Written, 10)
+	}
+	line := fmt.Sprintf("%s - %s [%s] %s %d %s %s %s\n",
+		host, user, time.Now().Add(-m.Duration).Format("02/Jan/2006:15:04:05 -0700"),
+		strconv.Quote(r.Method+" "+l.uri(r)+" "+r.Proto), m.Code, size,
+		strconv.Quote(r.Referer()), strconv.Quote(r.UserAgent()))
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, _ = io.WriteString(l.out, line)
+}
+
+// countingReader counts bytes of request body that were actually read by the handler
+type countingReader struct {
+	io.ReadCloser
+	n int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,96 @@
+package integration
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/felixge/httpsnoop"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccessLogs_Should_Count_Body_And_Redact_Headers_And_Query(t *testing.T) {
+	buf := captureDefaultLog(t)
+	h := accessLogsHandler(t, AccessLogConfig{
+		SuccessSampleRatio: 1,
+		Headers:            []string{"Authorization", "X-Tenant"},
+		Query:              true,
+		RedactHeaders:      []string{"authorization"},
+		RedactQueryParams:  []string{"token"},
+	})
+
+	// Content-Length header is not set for chunked requests, so bytes are counted while reading
+	req := httptest.NewRequest(http.MethodPost, "/api/users/v1?token=secret&page=2", io.MultiReader(strings.NewReader(`{"name":`), strings.NewReader(`"some"}`)))
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set("X-Tenant", "acme")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	out := buf.String()
+	assert.NotContains(t, out, "secret")
+	assert.Contains(t, out, "uri=\"/api/users/v1?page=2&token=%2A%2A%2A\"")
+	assert.Contains(t, out, "bytesIn=15")
+	assert.Contains(t, out, "headers.Authorization=***")
+	assert.Contains(t, out, "headers.X-Tenant=acme")
+}
+
+func TestAccessLogs_Should_Skip_Excluded_And_Sampled_Out_Requests(t *testing.T) {
+	buf := captureDefaultLog(t)
+	h := accessLogsHandler(t, AccessLogConfig{
+		ExcludePaths:       []string{"/api/internal/*"},
+		ExcludeStatuses:    []int{http.StatusNotFound},
+		SuccessSampleRatio: 0,
+	})
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/users/v1", nil))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/internal/ping?status=500", nil))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/users/v1?status=404", nil))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/users/v1?status=500", nil))
+
+	assert.Equal(t, 1, strings.Count(buf.String(), "msg=access"))
+	assert.Contains(t, buf.String(), "status=500")
+}
+
+func TestAccessLogs_Should_Write_Combined_Format(t *testing.T) {
+	var out bytes.Buffer
+	l, err := newAccessLogger(AccessLogConfig{Format: "combined", SuccessSampleRatio: 1}, &out)
+	require.NoError(t, err)
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("hello"))
+	})
+	req := httptest.NewRequest(http.MethodGet, "/api/users/v1", nil)
+	req.Header.Set("User-Agent", "curl/8.0")
+	rec := httptest.NewRecorder()
+
+	m := httpsnoop.CaptureMetrics(h, rec, req)
+	l.log(req, m, 0)
+
+	assert.Regexp(t, `^192\.0\.2\.1 - - \[[^]]+] "GET /api/users/v1 HTTP/1\.1" 200 5 "" "curl/8\.0"\n$`, out.String())
+}
+
+func accessLogsHandler(t *testing.T, cfg AccessLogConfig) http.Handler {
+	mdl, err := AccessLogsMiddleware(cfg)
+	require.NoError(t, err)
+	return mdl(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		status := http.StatusOK
+		if s := r.URL.Query().Get("status"); s == "404" {
+			status = http.StatusNotFound
+		} else if s == "500" {
+			status = http.StatusInternalServerError
+		}
+		w.WriteHeader(status)
+	}))
+}
+
+func captureDefaultLog(t *testing.T) *bytes.Buffer {
+	var buf bytes.Buffer
+	prev := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, nil)))
+	t.Cleanup(func() { slog.SetDefault(prev) })
+	return &buf
+}
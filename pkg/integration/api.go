@@ -10,21 +10,79 @@ import (
 	"golang-http-service/api"
 )
 
-func APIHandler(baseURL string, apiController api.StrictServerInterface, enableAuth bool, jwkSetURI string, allowedIssuers []string, roleDefs map[string]string) (http.Handler, error) {
+// APIOptions configure the api handler and its middlewares
+type APIOptions struct {
+	BaseURL         string
+	Http            HttpConfig
+	Auth            bool
+	JwkSetURI       string
+	AllowedIssuers  []string
+	RoleDefs        map[string]string // role name to JWT audience
+	DurationBuckets []float64
+}
+
+func APIHandler(apiController api.StrictServerInterface, opts APIOptions) (http.Handler, error) {
 	swagger, err := api.GetSwagger()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get embedded swagger spec; %w", err)
 	}
 	openapiValidationMiddleware := OpenapiValidationMiddleware(swagger)
+	routeMiddleware, err := RouteMiddleware(swagger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create route middleware; %w", err)
+	}
+	redMetricsMiddleware, err := RedMetricsMiddleware(swagger, opts.DurationBuckets)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create red metrics middleware; %w", err)
+	}
+	accessLogsMiddleware, err := AccessLogsMiddleware(opts.Http.AccessLogs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create access logs middleware; %w", err)
+	}
+	compressionMiddleware, err := CompressionMiddleware(opts.Http.Compression)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create compression middleware; %w", err)
+	}
+	bodyLimitMiddleware, err := BodyLimitMiddleware(swagger, opts.Http.MaxBodySize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create body limit middleware; %w", err)
+	}
 
-	middlewares := []api.MiddlewareFunc{openapiValidationMiddleware}
-	if enableAuth {
-		jwtMiddleware, err := JWTAuthMiddleware(jwkSetURI, allowedIssuers)
+	// the first middleware is the innermost: jwt and roles run first, so the limiters know the caller,
+	// and the concurrency limiter is right in front of the handler, so it observes handler latency only
+	var middlewares []api.MiddlewareFunc
+	if opts.Http.ConcurrencyLimit.Enabled {
+		concurrencyLimitMiddleware, err := ConcurrencyLimitMiddleware(swagger, opts.Http.ConcurrencyLimit, NewConcurrencyLimiter(opts.Http.ConcurrencyLimit))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create concurrency limit middleware; %w", err)
+		}
+		middlewares = append(middlewares, concurrencyLimitMiddleware)
+	}
+	if opts.Http.ResponseValidation.Enabled {
+		middlewares = append(middlewares, ResponseValidationMiddleware(opts.Http.ResponseValidation.FailOnViolation))
+	}
+	if opts.Http.Idempotency.Enabled {
+		idempotencyMiddleware, err := IdempotencyMiddleware(swagger, opts.Http.Idempotency, NewMemoryIdempotencyStore(opts.Http.Idempotency.MaxEntries))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create idempotency middleware; %w", err)
+		}
+		middlewares = append(middlewares, idempotencyMiddleware)
+	}
+	middlewares = append(middlewares, openapiValidationMiddleware)
+	if opts.Http.RateLimit.Enabled {
+		rateLimitMiddleware, err := RateLimitMiddleware(swagger, opts.Http.RateLimit, NewMemoryRateLimitStore())
+		if err != nil {
+			return nil, fmt.Errorf("failed to create rate limit middleware; %w", err)
+		}
+		middlewares = append(middlewares, rateLimitMiddleware)
+	}
+	if opts.Auth {
+		jwtMiddleware, err := JWTAuthMiddleware(opts.JwkSetURI, opts.AllowedIssuers)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create jwt middleware; %w", err)
 		}
+		middlewares = append(middlewares, AuthRolesMiddleware(opts.RoleDefs))
 		middlewares = append(middlewares, jwtMiddleware)
-		middlewares = append(middlewares, AuthRolesMiddleware(roleDefs))
 	}
 
 	strictHandler := api.NewStrictHandlerWithOptions(apiController,
@@ -37,12 +95,14 @@ func APIHandler(baseURL string, apiController api.StrictServerInterface, enableA
 	mux := http.NewServeMux()
 	mux.HandleFunc("/", HandleHTTPNotFound)
 	h := api.HandlerWithOptions(strictHandler, api.StdHTTPServerOptions{
-		BaseURL:          baseURL,
+		BaseURL:          opts.BaseURL,
 		BaseRouter:       mux,
 		Middlewares:      middlewares,
 		ErrorHandlerFunc: HandleHTTPBadRequest,
 	})
-	h = RequestURIMiddleware(RecoverMiddleware(TelemetryGlobalMiddleware(AccessLogsMiddleware(h))))
+	// the inner recovery lets telemetry and access logs see handler panics as regular 500 responses,
+	// the outermost one recovers panics of the middlewares themselves
+	h = RecoverMiddleware(RequestURIMiddleware(ClientAddressMiddleware(opts.Http.TrustForwardedFor)(routeMiddleware(TelemetryGlobalMiddleware(RequestLoggerMiddleware(redMetricsMiddleware(accessLogsMiddleware(compressionMiddleware(bodyLimitMiddleware(ContentNegotiationMiddleware(swagger)(RecoverMiddleware(h))))))))))))
 	return h, nil
 }
 
@@ -57,7 +117,7 @@ func BadRequestError(ctx context.Context, err error) api.BadRequestApplicationPr
 }
 
 func createAndRecordProblemDetail(ctx context.Context, status int, err error) api.ProblemDetail {
-	title := http.StatusText(status)
+	problemType := problemTypeOf(status, err)
 	span := trace.SpanFromContext(ctx)
 	var traceID string
 	if span.SpanContext().HasTraceID() {
@@ -66,14 +126,17 @@ func createAndRecordProblemDetail(ctx context.Context, status int, err error) ap
 	if err != nil {
 		span.RecordError(err)
 	}
-	span.SetStatus(codes.Error, title)
+	span.SetStatus(codes.Error, problemType.Title)
 	requestURI, _ := ctx.Value(RequestURIKey{}).(string)
+	redactor := currentRedactor()
 	return api.ProblemDetail{
-		Detail:   err,
-		Instance: requestURI,
-		Status:   status,
-		Title:    title,
-		TraceId:  traceID,
-		Type:     "about:blank",
+		Detail:        redactor.ProblemDetail(status, err),
+		Errors:        problemFieldErrors(err, redactor),
+		InvalidParams: problemInvalidParams(err, redactor),
+		Instance:      requestURI,
+		Status:        status,
+		Title:         problemType.Title,
+		TraceId:       traceID,
+		Type:          problemType.Type,
 	}
 }
@@ -0,0 +1,119 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const (
+	maxBodySizeExtension  = "x-max-body-size"
+	defaultMaxRequestBody = 1 << 20
+)
+
+// BodyLimitMiddleware caps request body size; operations can declare own limit in bytes with x-max-body-size
+// extension, others get maxBodySize. Requests with larger Content-Length are rejected right away, streamed bodies
+// fail on the read that crosses the limit, and the failure is reported as 413 by HandleHTTPBadRequest.
+// The limit is kept in the request context, so bodies transcoded to json are checked again
+func BodyLimitMiddleware(swagger *openapi3.T, maxBodySize int64) (func(next http.Handler) http.Handler, error) {
+	if maxBodySize <= 0 {
+		maxBodySize = defaultMaxRequestBody
+	}
+	limits, err := OperationBodyLimits(swagger)
+	if err != nil {
+		return nil, err
+	}
+	oversized, err := otel.Meter("golang-http-service").Int64Counter("http_server_oversized_requests",
+		metric.WithDescription("Requests rejected because of body size limit per openapi operation"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create oversized requests counter: %w", err)
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			operation := OperationIDFromContext(r.Context())
+			limit := maxBodySize
+			if l, ok := limits[operation]; ok {
+				limit = l
+			}
+			countOversized := func() {
+				oversized.Add(context.WithoutCancel(r.Context()), 1, metric.WithAttributes(attribute.String("operation", operation)))
+			}
+			if r.ContentLength > limit {
+				countOversized()
+				HandleHTTPRequestEntityTooLarge(w, r, fmt.Errorf("request body exceeds %d bytes", limit))
+				return
+			}
+			if r.Body != nil && r.Body != http.NoBody {
+				r.Body = &limitedBody{ReadCloser: http.MaxBytesReader(w, r.Body, limit), onLimit: countOversized}
+			}
+			ctx := context.WithValue(r.Context(), BodyLimitKey{}, &bodyLimit{size: limit, onLimit: countOversized})
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}, nil
+}
+
+// OperationBodyLimits reads x-max-body-size extensions from the spec
+func OperationBodyLimits(swagger *openapi3.T) (map[string]int64, error) {
+	limits := make(map[string]int64)
+	for _, path := range swagger.Paths.Map() {
+		for _, op := range path.Operations() {
+			ext, ok := op.Extensions[maxBodySizeExtension]
+			if !ok {
+				continue
+			}
+			raw, err := json.Marshal(ext)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s of %s operation: %w", maxBodySizeExtension, op.OperationID, err)
+			}
+			var limit int64
+			if err := json.Unmarshal(raw, &limit); err != nil || limit <= 0 {
+				return nil, fmt.Errorf("%s of %s operation has to be a positive number of bytes, got %s", maxBodySizeExtension, op.OperationID, raw)
+			}
+			limits[op.OperationID] = limit
+		}
+	}
+	return limits, nil
+}
+
+type BodyLimitKey struct{}
+
+type bodyLimit struct {
+	size    int64
+	onLimit func()
+}
+
+// checkBodyLimit fails with http.MaxBytesError when the body of the size exceeds the limit of the request
+func checkBodyLimit(r *http.Request, size int) error {
+	limit, ok := r.Context().Value(BodyLimitKey{}).(*bodyLimit)
+	if !ok || int64(size) <= limit.size {
+		return nil
+	}
+	limit.onLimit()
+	return &http.MaxBytesError{Limit: limit.size}
+}
+
+// limitedBody counts the request once when its body crosses the limit
+type limitedBody struct {
+	io.ReadCloser
+	onLimit func()
+	counted bool
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	var maxBytesErr *http.MaxBytesError
+	if err != nil && !b.counted && errors.As(err, &maxBytesErr) {
+		b.counted = true
+		b.onLimit()
+	}
+	return n, err
+}
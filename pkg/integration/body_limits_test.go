@@ -0,0 +1,67 @@
+package integration
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang-http-service/api"
+)
+
+func limitedHandler(t *testing.T, maxBodySize int64) http.Handler {
+	swagger, err := api.GetSwagger()
+	require.NoError(t, err)
+	routeMiddleware, err := RouteMiddleware(swagger)
+	require.NoError(t, err)
+	bodyLimit, err := BodyLimitMiddleware(swagger, maxBodySize)
+	require.NoError(t, err)
+	return routeMiddleware(bodyLimit(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := io.ReadAll(r.Body); err != nil {
+			HandleHTTPBadRequest(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})))
+}
+
+func TestBodyLimit_Should_Use_Operation_Limit_From_Spec(t *testing.T) {
+	swagger, err := api.GetSwagger()
+	require.NoError(t, err)
+
+	limits, err := OperationBodyLimits(swagger)
+
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int64{"CreateUser": 4096, "UpdateUser": 4096}, limits)
+}
+
+func TestBodyLimit_Should_Reject_Large_Content_Length(t *testing.T) {
+	rec := httptest.NewRecorder()
+
+	limitedHandler(t, 1<<20).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/users/v1", strings.NewReader(strings.Repeat("a", 5000))))
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+	assert.Equal(t, "application/problem+json", rec.Header().Get("Content-Type"))
+}
+
+func TestBodyLimit_Should_Reject_Streamed_Body_Above_Limit(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/api/users/v1", strings.NewReader(strings.Repeat("a", 5000)))
+	// unknown length, e.g. chunked transfer encoding
+	r.ContentLength = -1
+	rec := httptest.NewRecorder()
+
+	limitedHandler(t, 1<<20).ServeHTTP(rec, r)
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+}
+
+func TestBodyLimit_Should_Pass_Body_Within_Limit(t *testing.T) {
+	rec := httptest.NewRecorder()
+
+	limitedHandler(t, 16).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/users/v1", strings.NewReader(`{"name": "bob"}`)))
+
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+}
@@ -0,0 +1,111 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"strconv"
+	"sync"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+type BuildInfo struct {
+	Version   string `json:"version"`
+	Revision  string `json:"revision"`
+	Time      string `json:"time"`
+	Modified  bool   `json:"modified"`
+	GoVersion string `json:"goVersion"`
+}
+
+// version and revision can be set at build time for binaries built without VCS data, e.g.
+// -ldflags "-X golang-http-service/pkg/integration.version=v1.2.3 -X golang-http-service/pkg/integration.revision=abc"
+var (
+	version  string
+	revision string
+)
+
+var readBuildInfoOnce = sync.OnceValue(func() BuildInfo {
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		bi = nil
+	}
+	return buildInfoOf(bi, version, revision)
+})
+
+// buildInfoOf prefers values set with ldflags, then data embedded by the toolchain; missing values are "unknown"
+func buildInfoOf(bi *debug.BuildInfo, version string, revision string) BuildInfo {
+	info := BuildInfo{Version: "unknown", Revision: "unknown"}
+	if bi != nil {
+		info.GoVersion = bi.GoVersion
+		if bi.Main.Version != "" && bi.Main.Version != "(devel)" {
+			info.Version = bi.Main.Version
+		}
+		for _, s := range bi.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				info.Revision = s.Value
+			case "vcs.time":
+				info.Time = s.Value
+			case "vcs.modified":
+				info.Modified, _ = strconv.ParseBool(s.Value)
+			}
+		}
+	}
+	if version != "" {
+		info.Version = version
+	}
+	if revision != "" {
+		info.Revision = revision
+	}
+	return info
+}
+
+// ReadBuildInfo returns version data embedded by the go toolchain into the running binary
+func ReadBuildInfo() BuildInfo {
+	return readBuildInfoOnce()
+}
+
+func (b BuildInfo) attributes() []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("version", b.Version),
+		attribute.String("revision", b.Revision),
+		attribute.Bool("modified", b.Modified),
+		attribute.String("goversion", b.GoVersion),
+	}
+}
+
+func RegisterBuildInfoMetric() error {
+	meter := otel.Meter("golang-http-service")
+	attrs := metric.WithAttributes(ReadBuildInfo().attributes()...)
+	_, err := meter.Int64ObservableGauge("build_info",
+		metric.WithDescription("A metric with a constant '1' value labeled by version, revision, modified and goversion from which the app was built"),
+		metric.WithInt64Callback(func(_ context.Context, o metric.Int64Observer) error {
+			o.Observe(1, attrs)
+			return nil
+		}),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create build_info gauge: %w", err)
+	}
+	return nil
+}
+
+func InfoHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := struct {
+			Build BuildInfo `json:"build"`
+		}{
+			Build: ReadBuildInfo(),
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(body); err != nil {
+			slog.ErrorContext(r.Context(), "failed to write info to response", "err", err)
+		}
+	})
+}
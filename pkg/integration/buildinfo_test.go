@@ -0,0 +1,46 @@
+package integration
+
+import (
+	"runtime/debug"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildInfo_Should_Fall_Back_To_Unknown_Without_Build_Info(t *testing.T) {
+	info := buildInfoOf(nil, "", "")
+
+	assert.Equal(t, BuildInfo{Version: "unknown", Revision: "unknown"}, info)
+}
+
+func TestBuildInfo_Should_Read_Toolchain_Data_Without_Ldflags(t *testing.T) {
+	bi := &debug.BuildInfo{
+		GoVersion: "go1.23.0",
+		Main:      debug.Module{Version: "v1.2.3"},
+		Settings: []debug.BuildSetting{
+			{Key: "vcs.revision", Value: "abc"},
+			{Key: "vcs.time", Value: "2024-01-01T00:00:00Z"},
+			{Key: "vcs.modified", Value: "true"},
+		},
+	}
+
+	info := buildInfoOf(bi, "", "")
+
+	assert.Equal(t, BuildInfo{Version: "v1.2.3", Revision: "abc", Time: "2024-01-01T00:00:00Z", Modified: true, GoVersion: "go1.23.0"}, info)
+}
+
+func TestBuildInfo_Should_Prefer_Ldflags(t *testing.T) {
+	bi := &debug.BuildInfo{
+		GoVersion: "go1.23.0",
+		Main:      debug.Module{Version: "(devel)"},
+	}
+
+	info := buildInfoOf(bi, "", "")
+	assert.Equal(t, "unknown", info.Version)
+	assert.Equal(t, "unknown", info.Revision)
+
+	info = buildInfoOf(bi, "v2.0.0", "def")
+	assert.Equal(t, "v2.0.0", info.Version)
+	assert.Equal(t, "def", info.Revision)
+	assert.Equal(t, "go1.23.0", info.GoVersion)
+}
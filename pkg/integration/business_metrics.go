@@ -0,0 +1,51 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel/metric"
+	"golang-http-service/pkg/business/control"
+)
+
+// BusinessMetrics implements control.Metrics with OTel instruments
+type BusinessMetrics struct {
+	usersCreated           metric.Int64Counter
+	duplicateNameRejection metric.Int64Counter
+	lookupMisses           metric.Int64Counter
+	users                  metric.Int64Gauge
+}
+
+func NewBusinessMetrics(meter metric.Meter) (*BusinessMetrics, error) {
+	m := &BusinessMetrics{}
+	var err error
+	if m.usersCreated, err = meter.Int64Counter(control.MetricUsersCreated, metric.WithDescription("Users created")); err != nil {
+		return nil, fmt.Errorf("failed to create %s counter: %w", control.MetricUsersCreated, err)
+	}
+	if m.duplicateNameRejection, err = meter.Int64Counter(control.MetricUsersDuplicateNameRejections, metric.WithDescription("Users rejected because the name is taken")); err != nil {
+		return nil, fmt.Errorf("failed to create %s counter: %w", control.MetricUsersDuplicateNameRejections, err)
+	}
+	if m.lookupMisses, err = meter.Int64Counter(control.MetricUsersLookupMisses, metric.WithDescription("User lookups by id that found nothing")); err != nil {
+		return nil, fmt.Errorf("failed to create %s counter: %w", control.MetricUsersLookupMisses, err)
+	}
+	if m.users, err = meter.Int64Gauge(control.MetricUsers, metric.WithDescription("Users stored")); err != nil {
+		return nil, fmt.Errorf("failed to create %s gauge: %w", control.MetricUsers, err)
+	}
+	return m, nil
+}
+
+func (m *BusinessMetrics) UserCreated(ctx context.Context) {
+	m.usersCreated.Add(ctx, 1)
+}
+
+func (m *BusinessMetrics) DuplicateNameRejected(ctx context.Context) {
+	m.duplicateNameRejection.Add(ctx, 1)
+}
+
+func (m *BusinessMetrics) UserLookupMissed(ctx context.Context) {
+	m.lookupMisses.Add(ctx, 1)
+}
+
+func (m *BusinessMetrics) UserCount(ctx context.Context, count int) {
+	m.users.Record(ctx, int64(count))
+}
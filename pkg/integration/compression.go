@@ -0,0 +1,296 @@
+package integration
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zlib"
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	defaultCompressionMinSize  = 1024
+	defaultMaxDecompressedSize = 1 << 20
+	encodingIdentity           = "identity"
+	encodingGzip               = "gzip"
+	encodingDeflate            = "deflate"
+	encodingZstd               = "zstd"
+	// zstd frames declare the window the decoder allocates upfront, larger windows are rejected
+	zstdMaxWindowSize = 1 << 20
+)
+
+var (
+	defaultEncodings               = []string{encodingZstd, encodingGzip, encodingDeflate}
+	defaultCompressionContentTypes = []string{mediaTypeJSON, mediaTypeProblemJSON, "application/xml", "application/problem+xml", "application/msgpack", "text/*"}
+)
+
+// contentEncoding creates compressing writers and decompressing readers; writers are pooled, zstd ones are expensive.
+// Readers get the decompressed size limit, so decoders that allocate by frame headers can be bounded as well
+type contentEncoding struct {
+	name      string
+	writers   sync.Pool
+	newReader func(r io.Reader, maxSize int64) (io.ReadCloser, error)
+}
+
+type encodingWriter interface {
+	io.WriteCloser
+	Reset(w io.Writer)
+}
+
+var contentEncodings = map[string]*contentEncoding{
+	encodingGzip: {
+		name:      encodingGzip,
+		writers:   sync.Pool{New: func() any { return gzip.NewWriter(nil) }},
+		newReader: func(r io.Reader, _ int64) (io.ReadCloser, error) { return gzip.NewReader(r) },
+	},
+	// deflate content coding is zlib format (RFC 9110 8.4.1.2), not raw deflate stream
+	encodingDeflate: {
+		name: encodingDeflate,
+		writers: sync.Pool{New: func() any {
+			w, _ := zlib.NewWriterLevel(nil, zlib.DefaultCompression) // fails only for invalid level
+			return w
+		}},
+		newReader: func(r io.Reader, _ int64) (io.ReadCloser, error) { return zlib.NewReader(r) },
+	},
+	encodingZstd: {
+		name: encodingZstd,
+		writers: sync.Pool{New: func() any {
+			w, _ := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1)) // fails only for invalid options
+			return w
+		}},
+		newReader: func(r io.Reader, maxSize int64) (io.ReadCloser, error) {
+			d, err := zstd.NewReader(r,
+				zstd.WithDecoderConcurrency(1),
+				// the window is capped by max memory as well, it cannot be smaller than the minimal window
+				zstd.WithDecoderMaxMemory(max(uint64(maxSize), zstd.MinWindowSize)),
+				zstd.WithDecoderMaxWindow(zstdMaxWindowSize),
+			)
+			if err != nil {
+				return nil, err
+			}
+			return d.IOReadCloser(), nil
+		},
+	},
+}
+
+// CompressionMiddleware decompresses request bodies with a limit of decompressed size, so a small compressed body
+// cannot exhaust memory, and compresses responses of allowed content types that reach the minimum size
+func CompressionMiddleware(cfg CompressionConfig) (func(next http.Handler) http.Handler, error) {
+	encodings := cfg.Encodings
+	if len(encodings) == 0 {
+		encodings = defaultEncodings
+	}
+	for _, e := range encodings {
+		if _, ok := contentEncodings[e]; !ok {
+			return nil, fmt.Errorf("unsupported content encoding %s", e)
+		}
+	}
+	minSize := cfg.MinSize
+	if minSize <= 0 {
+		minSize = defaultCompressionMinSize
+	}
+	maxDecompressedSize := cfg.MaxDecompressedSize
+	if maxDecompressedSize <= 0 {
+		maxDecompressedSize = defaultMaxDecompressedSize
+	}
+	contentTypes := cfg.ContentTypes
+	if len(contentTypes) == 0 {
+		contentTypes = defaultCompressionContentTypes
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if encoding := r.Header.Get("Content-Encoding"); encoding != "" && !strings.EqualFold(encoding, encodingIdentity) {
+				if !decompressRequest(w, r, encoding, maxDecompressedSize) {
+					return
+				}
+			}
+			w.Header().Add("Vary", "Accept-Encoding")
+			encoding := negotiateEncoding(r.Header.Values("Accept-Encoding"), encodings)
+			if encoding == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			cw := &compressingResponseWriter{ResponseWriter: w, encoding: encoding, minSize: minSize, contentTypes: contentTypes, status: http.StatusOK}
+			defer cw.Close()
+			next.ServeHTTP(cw, r)
+		})
+	}, nil
+}
+
+// decompressRequest replaces request body with decompressed one, it writes problem response and returns false on failure
+func decompressRequest(w http.ResponseWriter, r *http.Request, encoding string, maxSize int64) bool {
+	e, ok := contentEncodings[strings.ToLower(encoding)]
+	if !ok {
+		w.Header().Set("Accept-Encoding", strings.Join(defaultEncodings, ", "))
+		HandleHTTPUnsupportedMediaType(w, r, fmt.Errorf("content encoding %s is not supported", encoding))
+		return false
+	}
+	reader, err := e.newReader(r.Body, maxSize)
+	if err != nil {
+		HandleHTTPBadRequest(w, r, fmt.Errorf("failed to decompress %s request body: %w", encoding, err))
+		return false
+	}
+	defer reader.Close()
+	data, err := io.ReadAll(io.LimitReader(reader, maxSize+1))
+	if err != nil {
+		HandleHTTPBadRequest(w, r, fmt.Errorf("failed to decompress %s request body: %w", encoding, err))
+		return false
+	}
+	if int64(len(data)) > maxSize {
+		HandleHTTPRequestEntityTooLarge(w, r, fmt.Errorf("decompressed request body exceeds %d bytes", maxSize))
+		return false
+	}
+	r.Body = io.NopCloser(bytes.NewReader(data))
+	r.ContentLength = int64(len(data))
+	r.Header.Del("Content-Encoding")
+	r.Header.Set("Content-Length", strconv.Itoa(len(data)))
+	return true
+}
+
+// negotiateEncoding picks the supported encoding with the highest quality in Accept-Encoding header,
+// encodings of equal quality are chosen in the configured order; nil means identity
+func negotiateEncoding(acceptEncoding []string, encodings []string) *contentEncoding {
+	qualities := make(map[string]float64)
+	for _, v := range acceptEncoding {
+		for _, part := range strings.Split(v, ",") {
+			name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+			q := 1.0
+			if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+				var err error
+				if q, err = strconv.ParseFloat(value, 64); err != nil {
+					continue
+				}
+			}
+			if name = strings.ToLower(strings.TrimSpace(name)); name != "" {
+				qualities[name] = q
+			}
+		}
+	}
+	var best *contentEncoding
+	var bestQuality float64
+	for _, name := range encodings {
+		q, ok := qualities[name]
+		if !ok {
+			q = qualities["*"]
+		}
+		if q > bestQuality {
+			best, bestQuality = contentEncodings[name], q
+		}
+	}
+	return best
+}
+
+// compressingResponseWriter buffers the body until it reaches the minimum size, then headers are sent
+// and the rest is streamed through the encoder; smaller responses are written as is on Close
+type compressingResponseWriter struct {
+	http.ResponseWriter
+	encoding     *contentEncoding
+	minSize      int
+	contentTypes []string
+	status       int
+	wroteHeader  bool
+	buf          bytes.Buffer
+	writer       encodingWriter
+	passthrough  bool
+}
+
+func (w *compressingResponseWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+}
+
+func (w *compressingResponseWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	switch {
+	case w.writer != nil:
+		return w.writer.Write(b)
+	case w.passthrough:
+		return w.ResponseWriter.Write(b)
+	}
+	if !w.compressible() {
+		w.passthrough = true
+		w.writeUnencodedHeader()
+		return w.ResponseWriter.Write(b)
+	}
+	w.buf.Write(b)
+	if w.buf.Len() >= w.minSize {
+		if err := w.startCompression(); err != nil {
+			return 0, err
+		}
+	}
+	return len(b), nil
+}
+
+func (w *compressingResponseWriter) compressible() bool {
+	header := w.Header()
+	if header.Get("Content-Encoding") != "" || w.status < http.StatusOK || w.status == http.StatusNoContent || w.status == http.StatusNotModified {
+		return false
+	}
+	mediaType, _, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		return false
+	}
+	for _, allowed := range w.contentTypes {
+		if strings.EqualFold(allowed, mediaType) || (strings.HasSuffix(allowed, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(allowed, "*"))) {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *compressingResponseWriter) writeUnencodedHeader() {
+	if w.status == http.StatusNotModified {
+		// the cached representation may be the encoded one, which has weak entity tag
+		weakenETag(w.Header())
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+}
+
+func (w *compressingResponseWriter) startCompression() error {
+	header := w.Header()
+	header.Set("Content-Encoding", w.encoding.name)
+	header.Del("Content-Length")
+	weakenETag(header)
+	w.ResponseWriter.WriteHeader(w.status)
+	w.writer = w.encoding.writers.Get().(encodingWriter)
+	w.writer.Reset(w.ResponseWriter)
+	_, err := w.writer.Write(w.buf.Bytes())
+	w.buf.Reset()
+	return err
+}
+
+func (w *compressingResponseWriter) Close() error {
+	if w.writer != nil {
+		err := w.writer.Close()
+		w.writer.Reset(nil)
+		w.encoding.writers.Put(w.writer)
+		w.writer = nil
+		return err
+	}
+	if w.passthrough {
+		return nil
+	}
+	w.writeUnencodedHeader()
+	if w.buf.Len() == 0 {
+		return nil
+	}
+	_, err := w.ResponseWriter.Write(w.buf.Bytes())
+	return err
+}
+
+// weakenETag marks strong entity tag as weak: the encoded representation differs from the identity one,
+// so they must not share a strong validator (RFC 9110 8.8.3)
+func weakenETag(header http.Header) {
+	if etag := header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		header.Set("ETag", "W/"+etag)
+	}
+}
@@ -0,0 +1,197 @@
+package integration
+
+import (
+	"bytes"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func compressingHandler(t *testing.T, body string, received *string) http.Handler {
+	compression, err := CompressionMiddleware(CompressionConfig{MinSize: 16, MaxDecompressedSize: 64})
+	require.NoError(t, err)
+	return compression(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if received != nil {
+			data, _ := io.ReadAll(r.Body)
+			*received = string(data)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(body))
+	}))
+}
+
+func TestNegotiateEncoding_Should_Pick_Highest_Quality(t *testing.T) {
+	tests := map[string]string{
+		"":                          "",
+		"gzip":                      "gzip",
+		"gzip, deflate, zstd":       "zstd",
+		"gzip;q=1, zstd;q=0.5":      "gzip",
+		"*":                         "zstd",
+		"*, zstd;q=0":               "gzip",
+		"br, identity":              "",
+		"GZIP;q=0.8, deflate;q=0.9": "deflate",
+	}
+	for accept, expected := range tests {
+		t.Run(accept, func(t *testing.T) {
+			e := negotiateEncoding([]string{accept}, defaultEncodings)
+			if expected == "" {
+				assert.Nil(t, e)
+			} else {
+				require.NotNil(t, e)
+				assert.Equal(t, expected, e.name)
+			}
+		})
+	}
+}
+
+func TestCompression_Should_Compress_Large_Responses(t *testing.T) {
+	body := `{"users": "` + strings.Repeat("a", 100) + `"}`
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+
+	compressingHandler(t, body, nil).ServeHTTP(rec, r)
+
+	assert.Equal(t, "gzip", rec.Header().Get("Content-Encoding"))
+	assert.Equal(t, "Accept-Encoding", rec.Header().Get("Vary"))
+	reader, err := gzip.NewReader(rec.Body)
+	require.NoError(t, err)
+	decompressed, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Equal(t, body, string(decompressed))
+}
+
+func TestCompression_Should_Not_Compress_Small_Responses(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Accept-Encoding", "zstd")
+	rec := httptest.NewRecorder()
+
+	compressingHandler(t, `{}`, nil).ServeHTTP(rec, r)
+
+	assert.Empty(t, rec.Header().Get("Content-Encoding"))
+	assert.Equal(t, `{}`, rec.Body.String())
+}
+
+func TestCompression_Should_Weaken_Entity_Tag_Of_Encoded_Responses(t *testing.T) {
+	compression, err := CompressionMiddleware(CompressionConfig{MinSize: 16})
+	require.NoError(t, err)
+	serve := func(status int, body string) *httptest.ResponseRecorder {
+		h := compression(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("ETag", `"3"`)
+			w.WriteHeader(status)
+			_, _ = w.Write([]byte(body))
+		}))
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Accept-Encoding", "gzip")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, r)
+		return rec
+	}
+
+	rec := serve(http.StatusOK, `{"users": "`+strings.Repeat("a", 100)+`"}`)
+	assert.Equal(t, "gzip", rec.Header().Get("Content-Encoding"))
+	etag := rec.Header().Get("ETag")
+	assert.Equal(t, `W/"3"`, etag)
+	assert.True(t, IfNoneMatch(&etag, ETag(3)))
+
+	rec = serve(http.StatusOK, `{}`)
+	assert.Empty(t, rec.Header().Get("Content-Encoding"))
+	assert.Equal(t, `"3"`, rec.Header().Get("ETag"))
+
+	rec = serve(http.StatusNotModified, "")
+	assert.Equal(t, `W/"3"`, rec.Header().Get("ETag"))
+}
+
+func TestCompression_Should_Decompress_Request_Within_Limit(t *testing.T) {
+	var received string
+	zw, err := zstd.NewWriter(nil)
+	require.NoError(t, err)
+	compressed := zw.EncodeAll([]byte(`{"name": "bob"}`), nil)
+	r := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(compressed))
+	r.Header.Set("Content-Encoding", "zstd")
+	rec := httptest.NewRecorder()
+
+	compressingHandler(t, `{}`, &received).ServeHTTP(rec, r)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, `{"name": "bob"}`, received)
+}
+
+func TestCompression_Should_Reject_Zip_Bomb(t *testing.T) {
+	var compressed bytes.Buffer
+	gw := gzip.NewWriter(&compressed)
+	_, _ = gw.Write(bytes.Repeat([]byte("a"), 1000))
+	require.NoError(t, gw.Close())
+	r := httptest.NewRequest(http.MethodPost, "/", &compressed)
+	r.Header.Set("Content-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+
+	compressingHandler(t, `{}`, nil).ServeHTTP(rec, r)
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+}
+
+func TestCompression_Should_Use_Zlib_Format_For_Deflate(t *testing.T) {
+	var received string
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	_, _ = zw.Write([]byte(`{"name": "bob"}`))
+	require.NoError(t, zw.Close())
+	body := `{"users": "` + strings.Repeat("a", 100) + `"}`
+	r := httptest.NewRequest(http.MethodPost, "/", &compressed)
+	r.Header.Set("Content-Encoding", "deflate")
+	r.Header.Set("Accept-Encoding", "deflate")
+	rec := httptest.NewRecorder()
+
+	compressingHandler(t, body, &received).ServeHTTP(rec, r)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, `{"name": "bob"}`, received)
+	assert.Equal(t, "deflate", rec.Header().Get("Content-Encoding"))
+	reader, err := zlib.NewReader(rec.Body)
+	require.NoError(t, err)
+	decompressed, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Equal(t, body, string(decompressed))
+}
+
+func TestCompression_Should_Reject_Zstd_Frame_With_Large_Window(t *testing.T) {
+	frame := []byte{
+		0x28, 0xb5, 0x2f, 0xfd, // magic number
+		0x00,             // frame header descriptor: no content size, not single segment
+		19 << 3,          // window descriptor: 1 << (10 + 19) = 512 MB
+		0x19, 0x00, 0x00, // last raw block of 3 bytes
+		'a', 'b', 'c',
+	}
+	r := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(frame))
+	r.Header.Set("Content-Encoding", "zstd")
+	rec := httptest.NewRecorder()
+	handled := false
+
+	compression, err := CompressionMiddleware(CompressionConfig{MaxDecompressedSize: 1024})
+	require.NoError(t, err)
+	compression(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { handled = true })).ServeHTTP(rec, r)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.False(t, handled)
+}
+
+func TestCompression_Should_Reject_Unknown_Request_Encoding(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("data"))
+	r.Header.Set("Content-Encoding", "br")
+	rec := httptest.NewRecorder()
+
+	compressingHandler(t, `{}`, nil).ServeHTTP(rec, r)
+
+	assert.Equal(t, http.StatusUnsupportedMediaType, rec.Code)
+	assert.Equal(t, "zstd, gzip, deflate", rec.Header().Get("Accept-Encoding"))
+}
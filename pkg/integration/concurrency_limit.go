@@ -0,0 +1,211 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const (
+	defaultInitialConcurrencyLimit = 20
+	defaultMinConcurrencyLimit     = 1
+	defaultMaxConcurrencyLimit     = 1000
+	defaultConcurrencyBackoff      = 0.9
+	defaultLowPriorityShare        = 0.8
+	defaultConcurrencyLatency      = time.Second
+	defaultConcurrencyRetryAfter   = time.Second
+)
+
+type requestPriority string
+
+const (
+	priorityCritical requestPriority = "critical" // never shed
+	priorityNormal   requestPriority = "normal"
+	priorityLow      requestPriority = "low" // shed first
+)
+
+// ConcurrencyLimiter adapts the number of concurrent requests with AIMD: the limit grows by one per limit of
+// requests that completed in time while the limiter was saturated, and is multiplied by backoff ratio when a request
+// is slower than its latency threshold; it backs off at most once per limit of completed requests, so slow requests
+// of one latency spike decrease the limit once
+type ConcurrencyLimiter struct {
+	mu               sync.Mutex
+	limit            float64
+	minLimit         float64
+	maxLimit         float64
+	backoff          float64
+	lowPriorityShare float64
+	inflight         int
+	sinceBackoff     int // requests completed since the last backoff
+}
+
+func NewConcurrencyLimiter(cfg ConcurrencyLimitConfig) *ConcurrencyLimiter {
+	l := &ConcurrencyLimiter{
+		limit:            float64(orDefaultInt(cfg.InitialLimit, defaultInitialConcurrencyLimit)),
+		minLimit:         float64(orDefaultInt(cfg.MinLimit, defaultMinConcurrencyLimit)),
+		maxLimit:         float64(orDefaultInt(cfg.MaxLimit, defaultMaxConcurrencyLimit)),
+		backoff:          cfg.BackoffRatio,
+		lowPriorityShare: cfg.LowPriorityShare,
+	}
+	if l.backoff <= 0 || l.backoff >= 1 {
+		l.backoff = defaultConcurrencyBackoff
+	}
+	if l.lowPriorityShare <= 0 || l.lowPriorityShare > 1 {
+		l.lowPriorityShare = defaultLowPriorityShare
+	}
+	l.limit = min(max(l.limit, l.minLimit), l.maxLimit)
+	return l
+}
+
+// acquire reserves a slot unless the request has to be shed; saturated tells if the limit was close to be reached
+func (l *ConcurrencyLimiter) acquire(priority requestPriority) (ok bool, saturated bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	inflight := float64(l.inflight)
+	switch priority {
+	case priorityLow:
+		if inflight >= l.limit*l.lowPriorityShare {
+			return false, true
+		}
+	case priorityNormal:
+		if inflight >= l.limit {
+			return false, true
+		}
+	}
+	l.inflight++
+	return true, inflight+1 >= l.limit/2
+}
+
+func (l *ConcurrencyLimiter) release(slow bool, saturated bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.inflight--
+	l.sinceBackoff++
+	switch {
+	case slow:
+		if float64(l.sinceBackoff) >= l.limit {
+			l.limit = max(l.minLimit, l.limit*l.backoff)
+			l.sinceBackoff = 0
+		}
+	case saturated:
+		l.limit = min(l.maxLimit, l.limit+1/l.limit)
+	}
+}
+
+func (l *ConcurrencyLimiter) state() (limit int, inflight int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return int(l.limit), l.inflight
+}
+
+// ConcurrencyLimitMiddleware sheds requests above the adaptive limit with 503 problem; requests of critical roles
+// are never shed, low priority operations are shed when inflight requests reach a share of the limit.
+// Latency threshold of an operation is x-slo latency from the spec, or the configured one
+func ConcurrencyLimitMiddleware(swagger *openapi3.T, cfg ConcurrencyLimitConfig, limiter *ConcurrencyLimiter) (func(next http.Handler) http.Handler, error) {
+	thresholds := make(map[string]time.Duration)
+	for _, path := range swagger.Paths.Map() {
+		for _, op := range path.Operations() {
+			if ext, ok := op.Extensions[sloExtension]; ok {
+				slo, err := parseSLO(ext)
+				if err != nil {
+					return nil, fmt.Errorf("failed to parse %s of %s operation: %w", sloExtension, op.OperationID, err)
+				}
+				if slo.Latency > 0 {
+					thresholds[op.OperationID] = slo.Latency
+				}
+			}
+		}
+	}
+	defaultThreshold := cfg.LatencyThreshold
+	if defaultThreshold <= 0 {
+		defaultThreshold = defaultConcurrencyLatency
+	}
+	retryAfter := cfg.RetryAfter
+	if retryAfter <= 0 {
+		retryAfter = defaultConcurrencyRetryAfter
+	}
+	lowPriority := make(map[string]bool)
+	for _, op := range cfg.LowPriorityOperations {
+		lowPriority[strings.ToLower(op)] = true
+	}
+
+	meter := otel.Meter("golang-http-service")
+	shed, err := meter.Int64Counter("http_server_concurrency_shed",
+		metric.WithDescription("Requests rejected by adaptive concurrency limiter per openapi operation and priority"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create shed requests counter: %w", err)
+	}
+	_, err = meter.Int64ObservableGauge("http_server_concurrency_limit",
+		metric.WithDescription("Current adaptive concurrency limit"),
+		metric.WithInt64Callback(func(_ context.Context, o metric.Int64Observer) error {
+			limit, _ := limiter.state()
+			o.Observe(int64(limit))
+			return nil
+		}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create concurrency limit gauge: %w", err)
+	}
+	_, err = meter.Int64ObservableGauge("http_server_concurrency_inflight",
+		metric.WithDescription("Requests that are being handled under the concurrency limit"),
+		metric.WithInt64Callback(func(_ context.Context, o metric.Int64Observer) error {
+			_, inflight := limiter.state()
+			o.Observe(int64(inflight))
+			return nil
+		}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create inflight requests gauge: %w", err)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			operation := OperationIDFromContext(r.Context())
+			priority := priorityNormal
+			roles, _ := r.Context().Value(AuthRoleKey{}).([]string)
+			switch {
+			case slices.ContainsFunc(roles, func(role string) bool { return slices.Contains(cfg.CriticalRoles, role) }):
+				priority = priorityCritical
+			case lowPriority[strings.ToLower(operation)]:
+				priority = priorityLow
+			}
+			ok, saturated := limiter.acquire(priority)
+			if !ok {
+				shed.Add(context.WithoutCancel(r.Context()), 1, metric.WithAttributes(
+					attribute.String("operation", operation),
+					attribute.String("priority", string(priority)),
+				))
+				w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(retryAfter)))
+				HandleHTTPServiceUnavailable(w, r, fmt.Errorf("server is overloaded, retry in %s", retryAfter))
+				return
+			}
+			threshold, ok := thresholds[operation]
+			if !ok {
+				threshold = defaultThreshold
+			}
+			start := time.Now()
+			defer func() {
+				limiter.release(time.Since(start) > threshold, saturated)
+			}()
+			next.ServeHTTP(w, r)
+		})
+	}, nil
+}
+
+func orDefaultInt(v, def int) int {
+	if v <= 0 {
+		return def
+	}
+	return v
+}
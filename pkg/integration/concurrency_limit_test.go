@@ -0,0 +1,102 @@
+package integration
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang-http-service/api"
+)
+
+func TestConcurrencyLimiter_Should_Increase_Additively_And_Decrease_Multiplicatively(t *testing.T) {
+	l := NewConcurrencyLimiter(ConcurrencyLimitConfig{InitialLimit: 2, MinLimit: 1, MaxLimit: 10, BackoffRatio: 0.5})
+	// one request stays in flight, so the limiter is saturated
+
+	_, _ = l.acquire(priorityNormal)
+	for i := 0; i < 4; i++ {
+		ok, saturated := l.acquire(priorityNormal)
+		require.True(t, ok)
+		l.release(false, saturated)
+	}
+	limit, _ := l.state()
+	assert.Equal(t, 3, limit)
+
+	ok, saturated := l.acquire(priorityNormal)
+	require.True(t, ok)
+	l.release(true, saturated)
+	limit, inflight := l.state()
+	assert.Equal(t, 1, limit)
+	assert.Equal(t, 1, inflight)
+}
+
+func TestConcurrencyLimiter_Should_Back_Off_Once_Per_Window(t *testing.T) {
+	l := NewConcurrencyLimiter(ConcurrencyLimitConfig{InitialLimit: 20, MinLimit: 1, MaxLimit: 100, BackoffRatio: 0.9})
+
+	// a latency spike makes all requests in flight slow
+	saturations := make([]bool, 20)
+	for i := range saturations {
+		ok, saturated := l.acquire(priorityNormal)
+		require.True(t, ok)
+		saturations[i] = saturated
+	}
+	for _, saturated := range saturations {
+		l.release(true, saturated)
+	}
+	limit, inflight := l.state()
+	assert.Equal(t, 18, limit)
+	assert.Equal(t, 0, inflight)
+
+	for i := 0; i < 18; i++ {
+		ok, saturated := l.acquire(priorityNormal)
+		require.True(t, ok)
+		l.release(true, saturated)
+	}
+	limit, _ = l.state()
+	assert.Equal(t, 16, limit)
+}
+
+func TestConcurrencyLimiter_Should_Shed_By_Priority(t *testing.T) {
+	l := NewConcurrencyLimiter(ConcurrencyLimitConfig{InitialLimit: 2, LowPriorityShare: 0.5})
+
+	ok, _ := l.acquire(priorityLow)
+	assert.True(t, ok)
+	ok, _ = l.acquire(priorityLow)
+	assert.False(t, ok)
+	ok, _ = l.acquire(priorityNormal)
+	assert.True(t, ok)
+	ok, _ = l.acquire(priorityNormal)
+	assert.False(t, ok)
+	ok, _ = l.acquire(priorityCritical)
+	assert.True(t, ok)
+}
+
+func TestConcurrencyLimit_Should_Reject_With_Service_Unavailable(t *testing.T) {
+	swagger, err := api.GetSwagger()
+	require.NoError(t, err)
+	routeMiddleware, err := RouteMiddleware(swagger)
+	require.NoError(t, err)
+	cfg := ConcurrencyLimitConfig{InitialLimit: 1, MinLimit: 1, CriticalRoles: []string{"superuser"}}
+	limiter := NewConcurrencyLimiter(cfg)
+	concurrencyLimit, err := ConcurrencyLimitMiddleware(swagger, cfg, limiter)
+	require.NoError(t, err)
+	h := routeMiddleware(concurrencyLimit(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})))
+	ok, _ := limiter.acquire(priorityNormal)
+	require.True(t, ok)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/users/v1/1", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, "1", rec.Header().Get("Retry-After"))
+	assert.Equal(t, "application/problem+json", rec.Header().Get("Content-Type"))
+
+	r := httptest.NewRequest(http.MethodGet, "/api/users/v1/1", nil)
+	r = r.WithContext(context.WithValue(r.Context(), AuthRoleKey{}, []string{"superuser"}))
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, r)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
@@ -0,0 +1,61 @@
+package integration
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang-http-service/api"
+)
+
+// ETag returns strong entity tag of the entity version
+func ETag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// LastModified formats modification time as HTTP date
+func LastModified(t time.Time) string {
+	return t.UTC().Format(http.TimeFormat)
+}
+
+// IfNoneMatch tells if a cached representation is current: header is `*` or lists the etag, compared weakly (RFC 9110)
+func IfNoneMatch(header *string, etag string) bool {
+	if header == nil {
+		return false
+	}
+	return matchETag(*header, etag, true)
+}
+
+// IfMatch tells if the precondition holds: header is absent, `*` or lists the etag, compared strongly (RFC 9110)
+func IfMatch(header *string, etag string) bool {
+	if header == nil {
+		return true
+	}
+	return matchETag(*header, etag, false)
+}
+
+func matchETag(header string, etag string, weak bool) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" {
+			return true
+		}
+		if strings.HasPrefix(tag, "W/") {
+			if !weak {
+				continue
+			}
+			tag = strings.TrimPrefix(tag, "W/")
+		}
+		if tag == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+func PreconditionFailedError(ctx context.Context, err error) api.PreconditionFailedApplicationProblemPlusJSONResponse {
+	p := createAndRecordProblemDetail(ctx, http.StatusPreconditionFailed, err)
+	return api.PreconditionFailedApplicationProblemPlusJSONResponse(p)
+}
@@ -0,0 +1,23 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConditional_Should_Compare_Entity_Tags(t *testing.T) {
+	etag := ETag(2)
+	header := func(v string) *string { return &v }
+
+	assert.Equal(t, `"2"`, etag)
+	assert.True(t, IfNoneMatch(header(`"1", W/"2"`), etag))
+	assert.True(t, IfNoneMatch(header("*"), etag))
+	assert.False(t, IfNoneMatch(header(`"1"`), etag))
+	assert.False(t, IfNoneMatch(nil, etag))
+
+	assert.True(t, IfMatch(nil, etag))
+	assert.True(t, IfMatch(header(`"1", "2"`), etag))
+	assert.False(t, IfMatch(header(`W/"2"`), etag))
+	assert.False(t, IfMatch(header(`"1"`), etag))
+}
@@ -1,26 +1,41 @@
 package integration
 
+import "time"
+
 type Config struct {
-	Http struct {
-		Port int32
-	}
+	Http     HttpConfig
 	Actuator struct {
 		Port int32
 	}
 	Telemetry struct {
+		Resource struct {
+			ServiceName      string   `yaml:"serviceName"`
+			ServiceNamespace string   `yaml:"serviceNamespace"`
+			ServiceVersion   string   `yaml:"serviceVersion"` // defaults to module version from build info
+			Environment      string   // deployment.environment attribute
+			Detectors        []string // container, host, process, os, sdk, env
+		}
 		Logs struct {
-			Level  string
-			Format string
+			Level   string
+			Loggers map[string]string // per logger levels, e.g. `http.access: WARN`; can be changed at runtime via /loggers
+			Format  string
+			Output  string // stderr, otlpgrpc, otlphttp; otlp outputs are written to stderr as well
+			Otlp    OtlpExporterConfig
 		}
 		Metrics struct {
-			Output string // noop, stdout, remote
+			Output          string // noop, stdout, prometheus, otlpgrpc, otlphttp
+			Otlp            OtlpExporterConfig
+			DurationBuckets []float64 `yaml:"durationBuckets"` // operation duration histogram buckets in seconds; x-slo latencies have to be among them
 		}
 		Traces struct {
-			Output string // noop, stdout, remote
+			Output   string // noop, stdout, otlpgrpc, otlphttp
+			Otlp     OtlpExporterConfig
+			Sampling TraceSamplingConfig
 		}
 	}
-	BaseUrl  string `yaml:"baseUrl"`
-	Petstore struct {
+	Redaction RedactionConfig
+	BaseUrl   string `yaml:"baseUrl"`
+	Petstore  struct {
 		Url string
 	}
 	Auth struct {
@@ -34,3 +49,100 @@ type Config struct {
 		}
 	}
 }
+
+type HttpConfig struct {
+	Port               int32
+	AccessLogs         AccessLogConfig          `yaml:"accessLogs"`
+	ResponseValidation ResponseValidationConfig `yaml:"responseValidation"`
+	Compression        CompressionConfig
+	MaxBodySize        int64                  `yaml:"maxBodySize"` // request body limit in bytes, operations can override it with x-max-body-size
+	RateLimit          RateLimitConfig        `yaml:"rateLimit"`
+	ConcurrencyLimit   ConcurrencyLimitConfig `yaml:"concurrencyLimit"`
+	Idempotency        IdempotencyConfig      `yaml:"idempotency"`
+	TrustForwardedFor  bool                   `yaml:"trustForwardedFor"` // take client IP from the right-most X-Forwarded-For entry, only behind a trusted proxy
+}
+
+// OtlpExporterConfig empty values fall back to the standard OTEL_EXPORTER_OTLP_* env variables
+type OtlpExporterConfig struct {
+	Endpoint    string            // full url, e.g. http://localhost:4317 or http://localhost:4318/v1/logs for otlphttp; http scheme disables TLS
+	Headers     map[string]string // e.g. auth headers required by a vendor
+	Compression string            // none, gzip
+	Timeout     time.Duration
+	Tls         struct {
+		CaFile             string `yaml:"caFile"`
+		CertFile           string `yaml:"certFile"`
+		KeyFile            string `yaml:"keyFile"`
+		InsecureSkipVerify bool   `yaml:"insecureSkipVerify"`
+	}
+}
+
+type RedactionConfig struct {
+	Patterns []string // regexps, matching parts of logged, traced and returned strings are masked
+	Keys     []string // log and span attribute names, e.g. authorization or password, which values are always masked
+	ProdMode bool     `yaml:"prodMode"` // hide details of server errors from problem responses
+}
+
+type AccessLogConfig struct {
+	Format             string   // json writes structured record via `http.access` logger, combined writes NCSA combined lines to stdout
+	ExcludePaths       []string `yaml:"excludePaths"`       // path.Match patterns, e.g. /api/users/v1/*
+	ExcludeStatuses    []int    `yaml:"excludeStatuses"`    // e.g. 404
+	SuccessSampleRatio float64  `yaml:"successSampleRatio"` // share of logged requests with status below 400; failures are always logged
+	Headers            []string // request headers added to the record
+	Query              bool     // add query string to the uri
+	RedactHeaders      []string `yaml:"redactHeaders"`     // captured headers with masked values, e.g. Authorization
+	RedactQueryParams  []string `yaml:"redactQueryParams"` // query params with masked values, e.g. access_token
+}
+
+type CompressionConfig struct {
+	Encodings           []string // zstd, gzip, deflate in the order of preference when client accepts several equally
+	MinSize             int      `yaml:"minSize"`             // smaller responses are not compressed, bytes
+	ContentTypes        []string `yaml:"contentTypes"`        // compressed response media types, `text/*` patterns are supported
+	MaxDecompressedSize int64    `yaml:"maxDecompressedSize"` // larger compressed request bodies are rejected with 413, bytes
+}
+
+type RateLimitConfig struct {
+	Enabled    bool
+	Default    RateLimit            // applied to operations without own limit; zero requests disables it
+	Operations map[string]RateLimit // by operation id, overrides x-rate-limit extension of the spec
+}
+
+type IdempotencyConfig struct {
+	Enabled    bool
+	TTL        time.Duration `yaml:"ttl"`        // how long responses are replayed for retries
+	MaxEntries int           `yaml:"maxEntries"` // stored keys per instance, the oldest are evicted above it
+}
+
+type ConcurrencyLimitConfig struct {
+	Enabled               bool
+	InitialLimit          int           `yaml:"initialLimit"`
+	MinLimit              int           `yaml:"minLimit"`
+	MaxLimit              int           `yaml:"maxLimit"`
+	BackoffRatio          float64       `yaml:"backoffRatio"`          // limit is multiplied by it on slow requests, at most once per limit of completed requests
+	LatencyThreshold      time.Duration `yaml:"latencyThreshold"`      // slow request threshold for operations without x-slo latency
+	CriticalRoles         []string      `yaml:"criticalRoles"`         // requests of these roles are never shed, e.g. superuser
+	LowPriorityOperations []string      `yaml:"lowPriorityOperations"` // operation ids that are shed first
+	LowPriorityShare      float64       `yaml:"lowPriorityShare"`      // share of the limit above which low priority requests are shed
+	RetryAfter            time.Duration `yaml:"retryAfter"`            // Retry-After of 503 responses
+}
+
+type ResponseValidationConfig struct {
+	Enabled         bool // validate api responses against the spec, meant for local runs and tests
+	FailOnViolation bool `yaml:"failOnViolation"` // respond with 500 instead of logging the violation
+}
+
+type TraceSamplingConfig struct {
+	Strategy               string   // always, never, ratio, ratelimited
+	Ratio                  float64  // share of sampled traces for ratio strategy
+	Rate                   float64  // max sampled traces per second for ratelimited strategy
+	ParentBased            bool     `yaml:"parentBased"`            // inherit decision from the parent span when it exists
+	AlwaysSampleOperations []string `yaml:"alwaysSampleOperations"` // openapi operation ids that are always sampled
+	// tail capture buffers not sampled traces in memory and exports them when a span fails or is too slow
+	AlwaysSampleErrors bool          `yaml:"alwaysSampleErrors"` // keep not sampled traces with a span that ends with error status
+	LatencyThreshold   time.Duration `yaml:"latencyThreshold"`   // keep not sampled traces with a span slower than this; 0 disables
+	MaxBufferedTraces  int           `yaml:"maxBufferedTraces"`  // oldest traces are evicted when the limit is reached
+	MaxSpansPerTrace   int           `yaml:"maxSpansPerTrace"`   // spans above the limit are dropped from the buffered trace
+}
+
+func (c TraceSamplingConfig) TailCaptureEnabled() bool {
+	return c.AlwaysSampleErrors || c.LatencyThreshold > 0
+}
@@ -0,0 +1,275 @@
+package integration
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/ugorji/go/codec"
+)
+
+// xml representation follows RFC 9457 appendix: object members are child elements, array items are `i` elements
+// inside the member element; element names can be overridden with `xml.name` of the schema
+const (
+	xmlRootName = "root"
+	xmlItemName = "i"
+)
+
+func jsonToXML(data []byte, schema *openapi3.Schema) ([]byte, error) {
+	v, err := decodeJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	enc := xml.NewEncoder(&buf)
+	name := xml.Name{Local: xmlRootName}
+	if schema != nil && schema.XML != nil {
+		name = xml.Name{Local: orDefault(schema.XML.Name, xmlRootName), Space: schema.XML.Namespace}
+	}
+	if err := encodeXMLValue(enc, name, v, schema); err != nil {
+		return nil, err
+	}
+	if err := enc.Flush(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func encodeXMLValue(enc *xml.Encoder, name xml.Name, v any, schema *openapi3.Schema) error {
+	start := xml.StartElement{Name: name}
+	switch val := v.(type) {
+	case nil:
+		return nil
+	case map[string]any:
+		if err := enc.EncodeToken(start); err != nil {
+			return err
+		}
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			propSchema := propertySchema(schema, k)
+			if err := encodeXMLValue(enc, xml.Name{Local: xmlElementName(propSchema, k)}, val[k], propSchema); err != nil {
+				return err
+			}
+		}
+		return enc.EncodeToken(start.End())
+	case []any:
+		if err := enc.EncodeToken(start); err != nil {
+			return err
+		}
+		itemSchema := itemsSchema(schema)
+		for _, item := range val {
+			if err := encodeXMLValue(enc, xml.Name{Local: xmlElementName(itemSchema, xmlItemName)}, item, itemSchema); err != nil {
+				return err
+			}
+		}
+		return enc.EncodeToken(start.End())
+	default:
+		return enc.EncodeElement(fmt.Sprint(val), start)
+	}
+}
+
+type xmlNode struct {
+	name     string
+	children []*xmlNode
+	text     strings.Builder
+}
+
+func xmlToJSON(data []byte, schema *openapi3.Schema) ([]byte, error) {
+	root, err := parseXML(data)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(xmlNodeValue(root, schema))
+}
+
+func parseXML(data []byte) (*xmlNode, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	var stack []*xmlNode
+	var root *xmlNode
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			n := &xmlNode{name: t.Name.Local}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, n)
+			} else if root == nil {
+				root = n
+			} else {
+				return nil, errors.New("xml document has more than one root element")
+			}
+			stack = append(stack, n)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text.Write(t)
+			}
+		}
+	}
+	if root == nil {
+		return nil, errors.New("xml document is empty")
+	}
+	return root, nil
+}
+
+// xmlNodeValue types element content by the schema; values that do not fit the schema are kept as strings,
+// so request validation reports them as any other invalid value
+func xmlNodeValue(n *xmlNode, schema *openapi3.Schema) any {
+	typ := ""
+	if schema != nil {
+		typ = schema.Type
+	}
+	if typ == "" {
+		switch {
+		case len(n.children) == 0:
+			typ = openapi3.TypeString
+		case n.children[0].name == xmlItemName:
+			typ = openapi3.TypeArray
+		default:
+			typ = openapi3.TypeObject
+		}
+	}
+	text := strings.TrimSpace(n.text.String())
+	switch typ {
+	case openapi3.TypeObject:
+		obj := make(map[string]any, len(n.children))
+		for _, child := range n.children {
+			name, propSchema := xmlProperty(schema, child.name)
+			obj[name] = xmlNodeValue(child, propSchema)
+		}
+		return obj
+	case openapi3.TypeArray:
+		items := make([]any, len(n.children))
+		for i, child := range n.children {
+			items[i] = xmlNodeValue(child, itemsSchema(schema))
+		}
+		return items
+	case openapi3.TypeInteger:
+		if i, err := strconv.ParseInt(text, 10, 64); err == nil {
+			return i
+		}
+	case openapi3.TypeNumber:
+		if f, err := strconv.ParseFloat(text, 64); err == nil {
+			return f
+		}
+	case openapi3.TypeBoolean:
+		if b, err := strconv.ParseBool(text); err == nil {
+			return b
+		}
+	}
+	return text
+}
+
+// xmlProperty finds schema property by element name, which is either `xml.name` of the property or its name
+func xmlProperty(schema *openapi3.Schema, element string) (string, *openapi3.Schema) {
+	if schema == nil {
+		return element, nil
+	}
+	for name, prop := range schema.Properties {
+		if prop.Value != nil && xmlElementName(prop.Value, name) == element {
+			return name, prop.Value
+		}
+	}
+	return element, nil
+}
+
+func propertySchema(schema *openapi3.Schema, name string) *openapi3.Schema {
+	if schema == nil {
+		return nil
+	}
+	if prop, ok := schema.Properties[name]; ok {
+		return prop.Value
+	}
+	return nil
+}
+
+func itemsSchema(schema *openapi3.Schema) *openapi3.Schema {
+	if schema == nil || schema.Items == nil {
+		return nil
+	}
+	return schema.Items.Value
+}
+
+func xmlElementName(schema *openapi3.Schema, name string) string {
+	if schema != nil && schema.XML != nil && schema.XML.Name != "" {
+		return schema.XML.Name
+	}
+	return name
+}
+
+func orDefault(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
+
+var msgpackHandle = func() *codec.MsgpackHandle {
+	h := &codec.MsgpackHandle{}
+	h.MapType = reflect.TypeOf(map[string]any(nil))
+	h.RawToString = true
+	h.WriteExt = true
+	return h
+}()
+
+func jsonToMsgpack(data []byte, _ *openapi3.Schema) ([]byte, error) {
+	v, err := decodeJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	var out []byte
+	if err := codec.NewEncoderBytes(&out, msgpackHandle).Encode(jsonNumbers(v)); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func msgpackToJSON(data []byte, _ *openapi3.Schema) ([]byte, error) {
+	var v any
+	if err := codec.NewDecoderBytes(data, msgpackHandle).Decode(&v); err != nil {
+		return nil, err
+	}
+	return json.Marshal(v)
+}
+
+// jsonNumbers converts json.Number to int64 or float64, msgpack has no arbitrary precision numbers
+func jsonNumbers(v any) any {
+	switch val := v.(type) {
+	case json.Number:
+		if i, err := val.Int64(); err == nil {
+			return i
+		}
+		f, _ := val.Float64()
+		return f
+	case map[string]any:
+		for k, item := range val {
+			val[k] = jsonNumbers(item)
+		}
+	case []any:
+		for i, item := range val {
+			val[i] = jsonNumbers(item)
+		}
+	}
+	return v
+}
@@ -0,0 +1,288 @@
+package integration
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/routers"
+)
+
+const (
+	mediaTypeJSON        = "application/json"
+	mediaTypeProblemJSON = "application/problem+json"
+)
+
+// bodyFormat transcodes bodies between json, that api handlers and validation work with, and another media type;
+// schema of the body is used where the media type has no own typing, it can be nil
+type bodyFormat struct {
+	mediaType        string
+	problemMediaType string
+	aliases          []string // request content types and Accept values that select the format
+	fromJSON         func(data []byte, schema *openapi3.Schema) ([]byte, error)
+	toJSON           func(data []byte, schema *openapi3.Schema) ([]byte, error)
+}
+
+// bodyFormats are in the order of preference, json is the default
+var bodyFormats = []*bodyFormat{
+	{
+		mediaType:        mediaTypeJSON,
+		problemMediaType: mediaTypeProblemJSON,
+		aliases:          []string{mediaTypeJSON, mediaTypeProblemJSON},
+	},
+	{
+		mediaType:        "application/xml",
+		problemMediaType: "application/problem+xml",
+		aliases:          []string{"application/xml", "text/xml", "application/problem+xml"},
+		fromJSON:         jsonToXML,
+		toJSON:           xmlToJSON,
+	},
+	{
+		mediaType:        "application/msgpack",
+		problemMediaType: "application/msgpack",
+		aliases:          []string{"application/msgpack", "application/x-msgpack", "application/vnd.msgpack"},
+		fromJSON:         jsonToMsgpack,
+		toJSON:           msgpackToJSON,
+	},
+}
+
+func (f *bodyFormat) isJSON() bool {
+	return f.fromJSON == nil
+}
+
+// ContentNegotiationMiddleware selects response format by Accept header and converts request bodies of
+// supported content types to json; unsupported formats are rejected with 406 and 415 problems,
+// the latter and all other problems are rendered in the negotiated format
+func ContentNegotiationMiddleware(swagger *openapi3.T) func(next http.Handler) http.Handler {
+	var problemSchema *openapi3.Schema
+	if ref, ok := swagger.Components.Schemas["ProblemDetail"]; ok {
+		problemSchema = ref.Value
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Accept")
+			format, ok := negotiateFormat(r.Header.Values("Accept"))
+			if !ok {
+				HandleHTTPNotAcceptable(w, r, fmt.Errorf("none of accepted media types is supported, use one of %s", supportedMediaTypes()))
+				return
+			}
+			route, _ := r.Context().Value(RouteKey{}).(*routers.Route)
+			if !format.isJSON() {
+				bw := &bufferedResponseWriter{ResponseWriter: w, status: http.StatusOK}
+				defer transcodeResponse(bw, r, format, route, problemSchema)
+				w = bw
+			}
+			if r.Body != nil && r.Body != http.NoBody {
+				if err := transcodeRequest(r, route); err != nil {
+					var unsupported *unsupportedMediaTypeError
+					if errors.As(err, &unsupported) {
+						HandleHTTPUnsupportedMediaType(w, r, err)
+					} else {
+						HandleHTTPBadRequest(w, r, err)
+					}
+					return
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+type unsupportedMediaTypeError struct {
+	mediaType string
+}
+
+func (e *unsupportedMediaTypeError) Error() string {
+	return fmt.Sprintf("content type %s is not supported, use one of %s", e.mediaType, supportedMediaTypes())
+}
+
+func transcodeRequest(r *http.Request, route *routers.Route) error {
+	contentType := r.Header.Get("Content-Type")
+	if contentType == "" {
+		// request validation reports missing content type where the body is expected
+		return nil
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return &unsupportedMediaTypeError{mediaType: contentType}
+	}
+	format := formatOf(mediaType)
+	if format == nil {
+		return &unsupportedMediaTypeError{mediaType: mediaType}
+	}
+	if format.isJSON() {
+		return nil
+	}
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read request body: %w", err)
+	}
+	converted, err := format.toJSON(data, requestSchema(route))
+	if err != nil {
+		return fmt.Errorf("failed to decode %s request body: %w", mediaType, err)
+	}
+	// the body limit is checked before transcoding, json of a compact format can be much larger
+	if err := checkBodyLimit(r, len(converted)); err != nil {
+		return fmt.Errorf("%s request body as json: %w", mediaType, err)
+	}
+	r.Body = io.NopCloser(bytes.NewReader(converted))
+	r.ContentLength = int64(len(converted))
+	r.Header.Set("Content-Type", mediaTypeJSON)
+	r.Header.Set("Content-Length", strconv.Itoa(len(converted)))
+	return nil
+}
+
+// transcodeResponse converts buffered json response to the negotiated format, other responses are written as is
+func transcodeResponse(bw *bufferedResponseWriter, r *http.Request, format *bodyFormat, route *routers.Route, problemSchema *openapi3.Schema) {
+	header := bw.ResponseWriter.Header()
+	mediaType, _, _ := mime.ParseMediaType(header.Get("Content-Type"))
+	if bw.body.Len() == 0 || (mediaType != mediaTypeJSON && mediaType != mediaTypeProblemJSON) {
+		bw.flush(r.Context())
+		return
+	}
+	schema := responseSchema(route, bw.status, mediaType)
+	contentType := format.mediaType
+	if mediaType == mediaTypeProblemJSON {
+		contentType = format.problemMediaType
+		if schema == nil {
+			schema = problemSchema
+		}
+	}
+	converted, err := format.fromJSON(bw.body.Bytes(), schema)
+	if err != nil {
+		clear(header)
+		HandleHTTPServerError(bw.ResponseWriter, r, fmt.Errorf("failed to encode %s response: %w", format.mediaType, err))
+		return
+	}
+	bw.body.Reset()
+	bw.body.Write(converted)
+	header.Set("Content-Type", contentType)
+	header.Del("Content-Length")
+	bw.flush(r.Context())
+}
+
+func requestSchema(route *routers.Route) *openapi3.Schema {
+	if route == nil || route.Operation == nil || route.Operation.RequestBody == nil || route.Operation.RequestBody.Value == nil {
+		return nil
+	}
+	return contentSchema(route.Operation.RequestBody.Value.Content, mediaTypeJSON)
+}
+
+func responseSchema(route *routers.Route, status int, mediaType string) *openapi3.Schema {
+	if route == nil || route.Operation == nil || route.Operation.Responses == nil {
+		return nil
+	}
+	response := route.Operation.Responses.Status(status)
+	if response == nil {
+		response = route.Operation.Responses.Default()
+	}
+	if response == nil || response.Value == nil {
+		return nil
+	}
+	return contentSchema(response.Value.Content, mediaType)
+}
+
+func contentSchema(content openapi3.Content, mediaType string) *openapi3.Schema {
+	if mt := content.Get(mediaType); mt != nil && mt.Schema != nil {
+		return mt.Schema.Value
+	}
+	return nil
+}
+
+func formatOf(mediaType string) *bodyFormat {
+	for _, f := range bodyFormats {
+		for _, alias := range f.aliases {
+			if strings.EqualFold(alias, mediaType) {
+				return f
+			}
+		}
+	}
+	return nil
+}
+
+func supportedMediaTypes() string {
+	mediaTypes := make([]string, len(bodyFormats))
+	for i, f := range bodyFormats {
+		mediaTypes[i] = f.mediaType
+	}
+	return strings.Join(mediaTypes, ", ")
+}
+
+// negotiateFormat picks the format with the highest quality in Accept header,
+// formats of equal quality are chosen in the order of preference; no header means json
+func negotiateFormat(accept []string) (*bodyFormat, bool) {
+	var ranges []string
+	for _, v := range accept {
+		for _, part := range strings.Split(v, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				ranges = append(ranges, part)
+			}
+		}
+	}
+	if len(ranges) == 0 {
+		return bodyFormats[0], true
+	}
+	var best *bodyFormat
+	var bestQuality float64
+	for _, f := range bodyFormats {
+		if q := acceptQuality(ranges, f); q > bestQuality {
+			best, bestQuality = f, q
+		}
+	}
+	return best, best != nil
+}
+
+// acceptQuality is quality of the most specific media range that matches one of the format aliases
+func acceptQuality(ranges []string, f *bodyFormat) float64 {
+	quality, specificity := 0.0, -1
+	for _, rng := range ranges {
+		mediaType, params, err := mime.ParseMediaType(rng)
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		for _, alias := range f.aliases {
+			s := mediaRangeSpecificity(mediaType, alias)
+			if s > specificity {
+				quality, specificity = q, s
+			}
+		}
+	}
+	return quality
+}
+
+// mediaRangeSpecificity is 2 for exact match, 1 for `type/*`, 0 for `*/*` and -1 when range does not match
+func mediaRangeSpecificity(mediaRange, mediaType string) int {
+	switch {
+	case strings.EqualFold(mediaRange, mediaType):
+		return 2
+	case mediaRange == "*/*":
+		return 0
+	case strings.HasSuffix(mediaRange, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(mediaRange, "*")):
+		return 1
+	}
+	return -1
+}
+
+// decodeJSON keeps numbers as json.Number, so integers are not turned into floats on the way to other formats
+func decodeJSON(data []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
@@ -0,0 +1,158 @@
+package integration
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ugorji/go/codec"
+	"golang-http-service/api"
+)
+
+func TestNegotiateFormat_Should_Pick_Highest_Quality(t *testing.T) {
+	tests := map[string]string{
+		"":                "application/json",
+		"*/*":             "application/json",
+		"application/xml": "application/xml",
+		"application/json;q=0.5, application/xml":    "application/xml",
+		"application/*;q=0.9, application/x-msgpack": "application/msgpack",
+		"application/xml;q=0, */*":                   "application/json",
+		"text/*":                                     "application/xml",
+	}
+	for accept, expected := range tests {
+		t.Run(accept, func(t *testing.T) {
+			var values []string
+			if accept != "" {
+				values = []string{accept}
+			}
+			f, ok := negotiateFormat(values)
+			require.True(t, ok)
+			assert.Equal(t, expected, f.mediaType)
+		})
+	}
+
+	_, ok := negotiateFormat([]string{"text/html"})
+	assert.False(t, ok)
+}
+
+// negotiatingHandler echoes json request body of createUser and returns users list for getUsers
+func negotiatingHandler(t *testing.T, received *string) http.Handler {
+	swagger, err := api.GetSwagger()
+	require.NoError(t, err)
+	routeMiddleware, err := RouteMiddleware(swagger)
+	require.NoError(t, err)
+	return routeMiddleware(ContentNegotiationMiddleware(swagger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			body, _ := io.ReadAll(r.Body)
+			*received = r.Header.Get("Content-Type") + " " + string(body)
+			HandleHTTPBadRequest(w, r, nil)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`[{"id": 1, "name": "bob"}]`))
+	})))
+}
+
+func TestContentNegotiation_Should_Render_XML(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/api/users/v1", nil)
+	r.Header.Set("Accept", "application/xml")
+	rec := httptest.NewRecorder()
+
+	negotiatingHandler(t, nil).ServeHTTP(rec, r)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/xml", rec.Header().Get("Content-Type"))
+	assert.Equal(t, "Accept", rec.Header().Get("Vary"))
+	assert.Contains(t, rec.Body.String(), "<users><user><id>1</id><name>bob</name></user></users>")
+}
+
+func TestContentNegotiation_Should_Convert_XML_Request_And_Problem(t *testing.T) {
+	var received string
+	r := httptest.NewRequest(http.MethodPost, "/api/users/v1", strings.NewReader(`<user><id>7</id><name>bob</name></user>`))
+	r.Header.Set("Content-Type", "application/xml; charset=utf-8")
+	r.Header.Set("Accept", "application/xml")
+	rec := httptest.NewRecorder()
+
+	negotiatingHandler(t, &received).ServeHTTP(rec, r)
+
+	assert.Equal(t, `application/json {"id":7,"name":"bob"}`, received)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, "application/problem+xml", rec.Header().Get("Content-Type"))
+	assert.Contains(t, rec.Body.String(), `<problem xmlns="urn:ietf:rfc:7807">`)
+	assert.Contains(t, rec.Body.String(), `<status>400</status>`)
+}
+
+func TestContentNegotiation_Should_Convert_Msgpack(t *testing.T) {
+	var received string
+	var body []byte
+	require.NoError(t, codec.NewEncoderBytes(&body, msgpackHandle).Encode(map[string]any{"id": 7, "name": "bob"}))
+	r := httptest.NewRequest(http.MethodPost, "/api/users/v1", strings.NewReader(string(body)))
+	r.Header.Set("Content-Type", "application/msgpack")
+	r.Header.Set("Accept", "application/msgpack")
+	rec := httptest.NewRecorder()
+
+	negotiatingHandler(t, &received).ServeHTTP(rec, r)
+
+	assert.Equal(t, `application/json {"id":7,"name":"bob"}`, received)
+	assert.Equal(t, "application/msgpack", rec.Header().Get("Content-Type"))
+	var problem map[string]any
+	require.NoError(t, codec.NewDecoderBytes(rec.Body.Bytes(), msgpackHandle).Decode(&problem))
+	assert.EqualValues(t, http.StatusBadRequest, problem["status"])
+}
+
+func TestContentNegotiation_Should_Limit_Transcoded_Request_Body(t *testing.T) {
+	swagger, err := api.GetSwagger()
+	require.NoError(t, err)
+	routeMiddleware, err := RouteMiddleware(swagger)
+	require.NoError(t, err)
+	bodyLimit, err := BodyLimitMiddleware(swagger, 0)
+	require.NoError(t, err)
+	handled := false
+	h := routeMiddleware(bodyLimit(ContentNegotiationMiddleware(swagger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handled = true
+	}))))
+	// empty strings take one byte in msgpack and three in json, the body fits the 4096 bytes limit of createUser
+	values := make([]any, 2000)
+	for i := range values {
+		values[i] = ""
+	}
+	var body []byte
+	require.NoError(t, codec.NewEncoderBytes(&body, msgpackHandle).Encode(map[string]any{"id": 7, "name": values}))
+	require.Less(t, len(body), 4096)
+	r := httptest.NewRequest(http.MethodPost, "/api/users/v1", strings.NewReader(string(body)))
+	r.Header.Set("Content-Type", "application/msgpack")
+	rec := httptest.NewRecorder()
+
+	h.ServeHTTP(rec, r)
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+	assert.False(t, handled)
+}
+
+func TestContentNegotiation_Should_Reject_Unsupported_Media_Types(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/api/users/v1", strings.NewReader(`name=bob`))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.Header.Set("Accept", "application/xml")
+	rec := httptest.NewRecorder()
+
+	negotiatingHandler(t, nil).ServeHTTP(rec, r)
+
+	assert.Equal(t, http.StatusUnsupportedMediaType, rec.Code)
+	assert.Equal(t, "application/problem+xml", rec.Header().Get("Content-Type"))
+
+	r = httptest.NewRequest(http.MethodGet, "/api/users/v1", nil)
+	r.Header.Set("Accept", "text/html")
+	rec = httptest.NewRecorder()
+
+	negotiatingHandler(t, nil).ServeHTTP(rec, r)
+
+	assert.Equal(t, http.StatusNotAcceptable, rec.Code)
+	var p api.ProblemDetail
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &p))
+	assert.Equal(t, http.StatusNotAcceptable, p.Status)
+}
@@ -0,0 +1,45 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+)
+
+// FanoutHandler sends every record to all handlers that are enabled for it
+type FanoutHandler []slog.Handler
+
+func (f FanoutHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, h := range f {
+		if h.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f FanoutHandler) Handle(ctx context.Context, record slog.Record) error {
+	var errs []error
+	for _, h := range f {
+		if h.Enabled(ctx, record.Level) {
+			errs = append(errs, h.Handle(ctx, record.Clone()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (f FanoutHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make(FanoutHandler, len(f))
+	for i, h := range f {
+		handlers[i] = h.WithAttrs(attrs)
+	}
+	return handlers
+}
+
+func (f FanoutHandler) WithGroup(name string) slog.Handler {
+	handlers := make(FanoutHandler, len(f))
+	for i, h := range f {
+		handlers[i] = h.WithGroup(name)
+	}
+	return handlers
+}
@@ -5,13 +5,13 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"log/slog"
 	"net/http"
 	"time"
 
 	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/trace"
 	"golang-http-service/api"
+	"golang-http-service/pkg/integration/logctx"
 )
 
 type HttpServer interface {
@@ -44,8 +44,14 @@ func (h *httpServer) Stop(ctx context.Context) error {
 	return h.srv.Shutdown(ctx)
 }
 
+// HandleHTTPBadRequest reports request errors; reading body that crossed the size limit is reported as 413
 func HandleHTTPBadRequest(w http.ResponseWriter, r *http.Request, err error) {
 	status := http.StatusBadRequest
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		status = http.StatusRequestEntityTooLarge
+		err = fmt.Errorf("request body exceeds %d bytes", maxBytesErr.Limit)
+	}
 	p := createAndRecordProblemDetail(r.Context(), status, err)
 	writeProblem(w, r, p)
 }
@@ -62,8 +68,50 @@ func HandleHTTPUnauthorized(w http.ResponseWriter, r *http.Request, err error) {
 	writeProblem(w, r, p)
 }
 
+func HandleHTTPNotAcceptable(w http.ResponseWriter, r *http.Request, err error) {
+	status := http.StatusNotAcceptable
+	p := createAndRecordProblemDetail(r.Context(), status, err)
+	writeProblem(w, r, p)
+}
+
+func HandleHTTPUnsupportedMediaType(w http.ResponseWriter, r *http.Request, err error) {
+	status := http.StatusUnsupportedMediaType
+	p := createAndRecordProblemDetail(r.Context(), status, err)
+	writeProblem(w, r, p)
+}
+
+func HandleHTTPRequestEntityTooLarge(w http.ResponseWriter, r *http.Request, err error) {
+	status := http.StatusRequestEntityTooLarge
+	p := createAndRecordProblemDetail(r.Context(), status, err)
+	writeProblem(w, r, p)
+}
+
+func HandleHTTPConflict(w http.ResponseWriter, r *http.Request, err error) {
+	status := http.StatusConflict
+	p := createAndRecordProblemDetail(r.Context(), status, err)
+	writeProblem(w, r, p)
+}
+
+func HandleHTTPUnprocessableEntity(w http.ResponseWriter, r *http.Request, err error) {
+	status := http.StatusUnprocessableEntity
+	p := createAndRecordProblemDetail(r.Context(), status, err)
+	writeProblem(w, r, p)
+}
+
+func HandleHTTPTooManyRequests(w http.ResponseWriter, r *http.Request, err error) {
+	status := http.StatusTooManyRequests
+	p := createAndRecordProblemDetail(r.Context(), status, err)
+	writeProblem(w, r, p)
+}
+
+func HandleHTTPServiceUnavailable(w http.ResponseWriter, r *http.Request, err error) {
+	status := http.StatusServiceUnavailable
+	p := createAndRecordProblemDetail(r.Context(), status, err)
+	writeProblem(w, r, p)
+}
+
 func HandleHTTPServerError(w http.ResponseWriter, r *http.Request, err error) {
-	slog.ErrorContext(r.Context(), "unexpected error occurred", "err", err)
+	logctx.From(r.Context()).ErrorContext(r.Context(), "unexpected error occurred", "err", err)
 
 	status := http.StatusInternalServerError
 	p := createAndRecordProblemDetail(r.Context(), status, err)
@@ -78,6 +126,6 @@ func writeProblem(w http.ResponseWriter, r *http.Request, p api.ProblemDetail) {
 	w.Header().Set("Content-Type", "application/problem+json")
 	w.WriteHeader(p.Status)
 	if err := json.NewEncoder(w).Encode(p); err != nil {
-		slog.ErrorContext(r.Context(), "failed to write problem to response", "err", err)
+		logctx.From(r.Context()).ErrorContext(r.Context(), "failed to write problem to response", "err", err)
 	}
 }
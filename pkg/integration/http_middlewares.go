@@ -4,9 +4,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log/slog"
+	"net"
 	"net/http"
 	"net/url"
+	"os"
 	"slices"
+	"strings"
 	"time"
 
 	jwtmiddleware "github.com/auth0/go-jwt-middleware/v2"
@@ -15,9 +19,12 @@ import (
 	"github.com/felixge/httpsnoop"
 	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/getkin/kin-openapi/openapi3filter"
-	nethttpmiddleware "github.com/oapi-codegen/nethttp-middleware"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"github.com/google/uuid"
 	"github.com/oapi-codegen/runtime/strictmiddleware/nethttp"
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"golang-http-service/pkg/integration/logctx"
 )
 
 func JWTAuthMiddleware(JwkSetUri string, issuers []string) (func(http.Handler) http.Handler, error) {
@@ -64,25 +71,35 @@ func AuthRolesMiddleware(roleDefs map[string]string) func(next http.Handler) htt
 	}
 }
 
+// OpenapiValidationMiddleware validates requests against the spec collecting all violations,
+// they are returned as `invalidParams` of the problem; route is resolved earlier by RouteMiddleware
 func OpenapiValidationMiddleware(swagger *openapi3.T) func(next http.Handler) http.Handler {
-	options := &nethttpmiddleware.Options{
-		SilenceServersWarning: true,
-		ErrorHandlerWithOpts: func(w http.ResponseWriter, message string, statusCode int, opts nethttpmiddleware.ErrorHandlerOpts) {
-			switch statusCode {
-			case http.StatusNotFound:
-				HandleHTTPNotFound(w, opts.Request)
-			case http.StatusBadRequest:
-				HandleHTTPBadRequest(w, opts.Request, errors.New(message))
-			default:
-				HandleHTTPServerError(w, opts.Request, errors.New(message))
+	options := &openapi3filter.Options{
+		ExcludeReadOnlyValidations: true,
+		AuthenticationFunc:         openapi3filter.NoopAuthenticationFunc,
+		MultiError:                 true,
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route, ok := r.Context().Value(RouteKey{}).(*routers.Route)
+			if !ok {
+				HandleHTTPNotFound(w, r)
+				return
+			}
+			pathParams, _ := r.Context().Value(routePathParamsKey{}).(map[string]string)
+			input := &openapi3filter.RequestValidationInput{
+				Request:    r,
+				PathParams: pathParams,
+				Route:      route,
+				Options:    options,
 			}
-		},
-		Options: openapi3filter.Options{
-			ExcludeReadOnlyValidations: true,
-			AuthenticationFunc:         openapi3filter.NoopAuthenticationFunc,
-		},
+			if err := openapi3filter.ValidateRequest(r.Context(), input); err != nil {
+				HandleHTTPBadRequest(w, r, newRequestValidationError(err))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
 	}
-	return nethttpmiddleware.OapiRequestValidatorWithOptions(swagger, options)
 }
 
 func TelemetryStrictMiddleware(f nethttp.StrictHTTPHandlerFunc, operationID string) nethttp.StrictHTTPHandlerFunc {
@@ -96,19 +113,40 @@ func TelemetryGlobalMiddleware(next http.Handler) http.Handler {
 	return otelhttp.NewHandler(next, "server", otelhttp.WithMessageEvents(otelhttp.ReadEvents, otelhttp.WriteEvents))
 }
 
-func AccessLogsMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		m := httpsnoop.CaptureMetrics(next, w, r)
-		logHTTPRequest(r, m)
-	})
+func AccessLogsMiddleware(cfg AccessLogConfig) (func(next http.Handler) http.Handler, error) {
+	accessLog, err := newAccessLogger(cfg, os.Stdout)
+	if err != nil {
+		return nil, err
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body := &countingReader{ReadCloser: r.Body}
+			if r.Body != nil && r.Body != http.NoBody {
+				r.Body = body
+			}
+			m := httpsnoop.CaptureMetrics(next, w, r)
+			accessLog.log(r, m, body.n)
+		})
+	}, nil
 }
 
+// RecoverMiddleware turns panics into 500 problem responses; the panic value and stack are logged and traced,
+// but never sent to the client. http.ErrAbortHandler is re-panicked, it is the way to abort response on purpose
 func RecoverMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		defer func() {
-			if err := recover(); err != nil {
-				err := fmt.Errorf("%+v", err)
-				HandleHTTPServerError(w, r, err)
+			if recovered := recover(); recovered != nil {
+				if recovered == http.ErrAbortHandler {
+					panic(recovered)
+				}
+				// requests without operation, e.g. unknown paths, are counted together to keep label cardinality bounded
+				operation := OperationIDFromContext(r.Context())
+				if operation == "" {
+					operation = unknownOperation
+				}
+				_ = recordPanic(r.Context(), operation, recovered)
+				p := createAndRecordProblemDetail(r.Context(), http.StatusInternalServerError, errPanicRecovered)
+				writeProblem(w, r, p)
 			}
 		}()
 		next.ServeHTTP(w, r)
@@ -117,6 +155,45 @@ func RecoverMiddleware(next http.Handler) http.Handler {
 
 type RequestURIKey struct{}
 
+type ClientAddressKey struct{}
+
+// ClientAddressMiddleware resolves IP address of the client; behind a trusted proxy it is the right-most
+// X-Forwarded-For entry, which the proxy appends, the entries before it are sent by the caller
+func ClientAddressMiddleware(trustForwardedFor bool) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			address := remoteHost(r)
+			if forwarded := r.Header.Values("X-Forwarded-For"); trustForwardedFor && len(forwarded) > 0 {
+				last := forwarded[len(forwarded)-1]
+				if client := strings.TrimSpace(last[strings.LastIndexByte(last, ',')+1:]); client != "" {
+					address = client
+				}
+			}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), ClientAddressKey{}, address)))
+		})
+	}
+}
+
+// clientIdentity identifies the caller by authenticated identity only, anonymous callers by IP address;
+// unverified credentials, e.g. API key headers, would let callers pose as others by changing them
+func clientIdentity(r *http.Request) string {
+	if claims, ok := r.Context().Value(jwtmiddleware.ContextKey{}).(*validator.ValidatedClaims); ok && claims.RegisteredClaims.Subject != "" {
+		return "sub:" + claims.RegisteredClaims.Subject
+	}
+	if address, ok := r.Context().Value(ClientAddressKey{}).(string); ok {
+		return "ip:" + address
+	}
+	return "ip:" + remoteHost(r)
+}
+
+func remoteHost(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
 func RequestURIMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
@@ -125,3 +202,65 @@ func RequestURIMiddleware(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r)
 	})
 }
+
+// RequestLoggerMiddleware makes request id and operation id available to logctx.From for the rest of the request;
+// request id is taken from X-Request-Id header when it looks sane, otherwise it is generated
+func RequestLoggerMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get("X-Request-Id")
+		if !validRequestID(requestID) {
+			requestID = uuid.NewString()
+		}
+		w.Header().Set("X-Request-Id", requestID)
+		attrs := []slog.Attr{slog.String("requestId", requestID)}
+		if operationID := OperationIDFromContext(r.Context()); operationID != "" {
+			attrs = append(attrs, slog.String("operationId", operationID))
+		}
+		r = r.WithContext(logctx.NewContext(r.Context(), attrs...))
+		next.ServeHTTP(w, r)
+	})
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for _, c := range id {
+		if c < '!' || c > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+type RouteKey struct{}
+
+type routePathParamsKey struct{}
+
+// RouteMiddleware resolves OpenAPI route before any other middleware runs,
+// so telemetry, sampling and limits can be applied per operation
+func RouteMiddleware(swagger *openapi3.T) (func(next http.Handler) http.Handler, error) {
+	router, err := gorillamux.NewRouter(swagger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create openapi router: %w", err)
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if route, pathParams, err := router.FindRoute(r); err == nil {
+				ctx := context.WithValue(r.Context(), RouteKey{}, route)
+				r = r.WithContext(context.WithValue(ctx, routePathParamsKey{}, pathParams))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}, nil
+}
+
+// unknownOperation labels metrics of requests that do not match any operation of the spec
+const unknownOperation = "unknown"
+
+func OperationIDFromContext(ctx context.Context) string {
+	if route, ok := ctx.Value(RouteKey{}).(*routers.Route); ok && route.Operation != nil {
+		return route.Operation.OperationID
+	}
+	return ""
+}
@@ -0,0 +1,275 @@
+package integration
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"golang-http-service/pkg/integration/logctx"
+)
+
+const (
+	idempotencyKeyHeader         = "Idempotency-Key"
+	idempotencyReplayedHeader    = "Idempotent-Replayed"
+	defaultIdempotencyTTL        = 24 * time.Hour
+	defaultIdempotencyMaxEntries = 10000
+	idempotencySweepInterval     = time.Minute
+)
+
+var (
+	errIdempotencyInProgress = errors.New("request with the same idempotency key is in progress")
+	errIdempotencyKeyReused  = errors.New("idempotency key is already used for a different request")
+)
+
+func isIdempotencyInProgress(err error) bool {
+	return errors.Is(err, errIdempotencyInProgress)
+}
+
+func isIdempotencyKeyReused(err error) bool {
+	return errors.Is(err, errIdempotencyKeyReused)
+}
+
+// IdempotencyRecord is the state of an idempotency key; the response is set once the first request completes
+type IdempotencyRecord struct {
+	Fingerprint string // hash of method, path and body of the first request
+	Completed   bool
+	Status      int
+	Header      http.Header
+	Body        []byte
+}
+
+// IdempotencyStore keeps idempotency records by key; MemoryIdempotencyStore works per instance,
+// a store backed by shared storage (e.g. redis) makes retries safe across instances
+type IdempotencyStore interface {
+	// Reserve returns the record of the key, or creates an incomplete one and returns reserved=true
+	Reserve(ctx context.Context, key string, fingerprint string, ttl time.Duration) (record IdempotencyRecord, reserved bool, err error)
+	// Complete stores the response of the reserved key
+	Complete(ctx context.Context, key string, record IdempotencyRecord, ttl time.Duration) error
+	// Release removes the reserved key, so the request can be retried
+	Release(ctx context.Context, key string) error
+}
+
+// IdempotencyMiddleware stores the first response of operations that declare Idempotency-Key header in the spec
+// and replays it for requests with the same key; keys are scoped to the operation and JWT subject, or client address of anonymous callers. Reusing the key with
+// a different request gets 422, a retry while the first request runs gets 409. Server errors are not stored, so the
+// request can be retried. Store failures do not block requests
+func IdempotencyMiddleware(swagger *openapi3.T, cfg IdempotencyConfig, store IdempotencyStore) (func(next http.Handler) http.Handler, error) {
+	operations := idempotentOperations(swagger)
+	ttl := cfg.TTL
+	if ttl <= 0 {
+		ttl = defaultIdempotencyTTL
+	}
+	replays, err := otel.Meter("golang-http-service").Int64Counter("http_server_idempotent_replays",
+		metric.WithDescription("Responses replayed for retried requests with idempotency key per openapi operation"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create idempotent replays counter: %w", err)
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			operation := OperationIDFromContext(r.Context())
+			idempotencyKey := r.Header.Get(idempotencyKeyHeader)
+			if idempotencyKey == "" || !operations[strings.ToLower(operation)] {
+				next.ServeHTTP(w, r)
+				return
+			}
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				HandleHTTPBadRequest(w, r, fmt.Errorf("failed to read request body: %w", err))
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			ctx := r.Context()
+			key := operation + "|" + clientIdentity(r) + "|" + idempotencyKey
+			fingerprint := requestFingerprint(r, body)
+			record, reserved, err := store.Reserve(ctx, key, fingerprint, ttl)
+			if err != nil {
+				logctx.From(ctx).WarnContext(ctx, "idempotency store failed, request is not deduplicated", "err", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+			if !reserved {
+				switch {
+				case record.Fingerprint != fingerprint:
+					HandleHTTPUnprocessableEntity(w, r, errIdempotencyKeyReused)
+				case !record.Completed:
+					w.Header().Set("Retry-After", "1")
+					HandleHTTPConflict(w, r, errIdempotencyInProgress)
+				default:
+					replays.Add(context.WithoutCancel(ctx), 1, metric.WithAttributes(attribute.String("operation", operation)))
+					for name, values := range record.Header {
+						w.Header()[name] = slices.Clone(values)
+					}
+					w.Header().Set(idempotencyReplayedHeader, "true")
+					w.WriteHeader(record.Status)
+					if _, err := w.Write(record.Body); err != nil {
+						logctx.From(ctx).ErrorContext(ctx, "failed to write replayed response", "err", err)
+					}
+				}
+				return
+			}
+
+			// the record is updated even if the client is gone, so its retry gets the response
+			storeCtx := context.WithoutCancel(ctx)
+			before := w.Header().Clone()
+			bw := &bufferedResponseWriter{ResponseWriter: w, status: http.StatusOK}
+			defer func() {
+				if bw.status >= http.StatusInternalServerError || !bw.wroteHeader {
+					if err := store.Release(storeCtx, key); err != nil {
+						logctx.From(ctx).WarnContext(ctx, "failed to release idempotency key", "err", err)
+					}
+				}
+			}()
+			next.ServeHTTP(bw, r)
+			if bw.wroteHeader && bw.status < http.StatusInternalServerError {
+				record := IdempotencyRecord{
+					Fingerprint: fingerprint,
+					Completed:   true,
+					Status:      bw.status,
+					Header:      handlerHeaders(before, w.Header()),
+					Body:        bytes.Clone(bw.body.Bytes()),
+				}
+				if err := store.Complete(storeCtx, key, record, ttl); err != nil {
+					logctx.From(ctx).WarnContext(ctx, "failed to store idempotent response", "err", err)
+				}
+			}
+			bw.flush(ctx)
+		})
+	}, nil
+}
+
+// idempotentOperations returns lower case ids of operations with Idempotency-Key header parameter
+func idempotentOperations(swagger *openapi3.T) map[string]bool {
+	operations := make(map[string]bool)
+	for _, path := range swagger.Paths.Map() {
+		for _, op := range path.Operations() {
+			params := slices.Concat(path.Parameters, op.Parameters)
+			for _, p := range params {
+				if p.Value != nil && p.Value.In == openapi3.ParameterInHeader && http.CanonicalHeaderKey(p.Value.Name) == idempotencyKeyHeader {
+					operations[strings.ToLower(op.OperationID)] = true
+				}
+			}
+		}
+	}
+	return operations
+}
+
+func requestFingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(r.Method + " " + r.URL.RequestURI() + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// handlerHeaders returns headers set by the inner handlers, headers of outer middlewares are set again on replay
+func handlerHeaders(before, after http.Header) http.Header {
+	header := make(http.Header)
+	for name, values := range after {
+		if !slices.Equal(before[name], values) {
+			header[name] = slices.Clone(values)
+		}
+	}
+	return header
+}
+
+// MemoryIdempotencyStore keeps idempotency records in memory; expired records are removed periodically and
+// the oldest keys are evicted above the maximum number of entries, so fresh keys cannot exhaust memory
+type MemoryIdempotencyStore struct {
+	mu         sync.Mutex
+	records    map[string]*list.Element
+	order      *list.List // of *memoryIdempotencyRecord, the oldest reservation first
+	maxEntries int
+	lastSweep  time.Time
+	now        func() time.Time
+}
+
+type memoryIdempotencyRecord struct {
+	IdempotencyRecord
+	key       string
+	expiresAt time.Time
+}
+
+func NewMemoryIdempotencyStore(maxEntries int) *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{
+		records:    make(map[string]*list.Element),
+		order:      list.New(),
+		maxEntries: orDefaultInt(maxEntries, defaultIdempotencyMaxEntries),
+		lastSweep:  time.Now(),
+		now:        time.Now,
+	}
+}
+
+func (s *MemoryIdempotencyStore) Reserve(_ context.Context, key string, fingerprint string, ttl time.Duration) (IdempotencyRecord, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	if now.Sub(s.lastSweep) >= idempotencySweepInterval {
+		s.sweep(now)
+	}
+	if e, ok := s.records[key]; ok {
+		if rec := e.Value.(*memoryIdempotencyRecord); now.Before(rec.expiresAt) {
+			return rec.IdempotencyRecord, false, nil
+		}
+		s.remove(e)
+	}
+	for s.order.Len() >= s.maxEntries {
+		s.remove(s.order.Front())
+	}
+	rec := &memoryIdempotencyRecord{IdempotencyRecord: IdempotencyRecord{Fingerprint: fingerprint}, key: key, expiresAt: now.Add(ttl)}
+	s.records[key] = s.order.PushBack(rec)
+	return IdempotencyRecord{}, true, nil
+}
+
+func (s *MemoryIdempotencyStore) Complete(_ context.Context, key string, record IdempotencyRecord, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.records[key]
+	if !ok {
+		// the reservation was evicted while the request ran, the response is not kept
+		return nil
+	}
+	rec := e.Value.(*memoryIdempotencyRecord)
+	rec.IdempotencyRecord = record
+	rec.expiresAt = s.now().Add(ttl)
+	return nil
+}
+
+func (s *MemoryIdempotencyStore) Release(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e, ok := s.records[key]; ok {
+		s.remove(e)
+	}
+	return nil
+}
+
+func (s *MemoryIdempotencyStore) remove(e *list.Element) {
+	delete(s.records, e.Value.(*memoryIdempotencyRecord).key)
+	s.order.Remove(e)
+}
+
+func (s *MemoryIdempotencyStore) sweep(now time.Time) {
+	for e := s.order.Front(); e != nil; {
+		next := e.Next()
+		if !now.Before(e.Value.(*memoryIdempotencyRecord).expiresAt) {
+			s.remove(e)
+		}
+		e = next
+	}
+	s.lastSweep = now
+}
@@ -0,0 +1,134 @@
+package integration
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang-http-service/api"
+)
+
+func idempotentHandler(t *testing.T, status *int) (http.Handler, *int) {
+	swagger, err := api.GetSwagger()
+	require.NoError(t, err)
+	routeMiddleware, err := RouteMiddleware(swagger)
+	require.NoError(t, err)
+	idempotency, err := IdempotencyMiddleware(swagger, IdempotencyConfig{TTL: time.Hour}, NewMemoryIdempotencyStore(0))
+	require.NoError(t, err)
+	calls := 0
+	return routeMiddleware(idempotency(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(*status)
+		_, _ = w.Write([]byte(`{"id":1}`))
+	}))), &calls
+}
+
+func createUserRequest(key, body string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/api/users/v1", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("Idempotency-Key", key)
+	return r
+}
+
+func TestIdempotency_Should_Replay_Stored_Response(t *testing.T) {
+	status := http.StatusCreated
+	h, calls := idempotentHandler(t, &status)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, createUserRequest("k", `{"name":"bob"}`))
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Empty(t, rec.Header().Get("Idempotent-Replayed"))
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, createUserRequest("k", `{"name":"bob"}`))
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, "true", rec.Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"id":1}`, rec.Body.String())
+	assert.Equal(t, 1, *calls)
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, createUserRequest("k", `{"name":"alice"}`))
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Contains(t, rec.Body.String(), IdempotencyKeyReusedProblem.Type)
+	assert.Equal(t, 1, *calls)
+}
+
+func TestIdempotency_Should_Not_Store_Server_Errors(t *testing.T) {
+	status := http.StatusInternalServerError
+	h, calls := idempotentHandler(t, &status)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, createUserRequest("k", `{"name":"bob"}`))
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+
+	status = http.StatusCreated
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, createUserRequest("k", `{"name":"bob"}`))
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, 2, *calls)
+}
+
+func TestIdempotency_Should_Scope_Anonymous_Keys_By_Client_Address(t *testing.T) {
+	status := http.StatusCreated
+	h, calls := idempotentHandler(t, &status)
+	from := func(address string) *http.Request {
+		r := createUserRequest("k", `{"name":"bob"}`)
+		r.RemoteAddr = address + ":1234"
+		return r
+	}
+
+	for _, r := range []*http.Request{from("192.0.2.1"), from("192.0.2.2")} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, r)
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Empty(t, rec.Header().Get("Idempotent-Replayed"))
+	}
+	assert.Equal(t, 2, *calls)
+}
+
+func TestMemoryIdempotencyStore_Should_Report_In_Progress_And_Expire(t *testing.T) {
+	now := time.Now()
+	store := NewMemoryIdempotencyStore(0)
+	store.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	_, reserved, err := store.Reserve(ctx, "k", "f", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, reserved)
+
+	rec, reserved, _ := store.Reserve(ctx, "k", "f", time.Minute)
+	assert.False(t, reserved)
+	assert.False(t, rec.Completed)
+
+	now = now.Add(time.Minute)
+	_, reserved, _ = store.Reserve(ctx, "k", "f", time.Minute)
+	assert.True(t, reserved)
+}
+
+func TestMemoryIdempotencyStore_Should_Evict_Oldest_Entries(t *testing.T) {
+	store := NewMemoryIdempotencyStore(2)
+	ctx := context.Background()
+
+	for _, key := range []string{"a", "b", "c"} {
+		_, reserved, err := store.Reserve(ctx, key, "f", time.Minute)
+		require.NoError(t, err)
+		assert.True(t, reserved)
+	}
+	require.NoError(t, store.Complete(ctx, "b", IdempotencyRecord{Fingerprint: "f", Completed: true, Status: http.StatusCreated}, time.Minute))
+
+	assert.Len(t, store.records, 2)
+	assert.NotContains(t, store.records, "a")
+	rec, reserved, _ := store.Reserve(ctx, "b", "f", time.Minute)
+	assert.False(t, reserved)
+	assert.True(t, rec.Completed)
+	_, reserved, _ = store.Reserve(ctx, "a", "f", time.Minute)
+	assert.True(t, reserved)
+	assert.NotContains(t, store.records, "b")
+}
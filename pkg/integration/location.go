@@ -0,0 +1,23 @@
+package integration
+
+import (
+	"context"
+	"net/url"
+	"strings"
+
+	"github.com/getkin/kin-openapi/routers"
+)
+
+// Location returns path of the resource for Location header, prefixed with the path of openapi server the request is
+// routed to, e.g. `/users/v1/1` becomes `/api/users/v1/1`
+func Location(ctx context.Context, path string) string {
+	route, ok := ctx.Value(RouteKey{}).(*routers.Route)
+	if !ok || route.Server == nil {
+		return path
+	}
+	serverURL, err := url.Parse(route.Server.URL)
+	if err != nil {
+		return path
+	}
+	return strings.TrimSuffix(serverURL.Path, "/") + path
+}
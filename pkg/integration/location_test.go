@@ -0,0 +1,28 @@
+package integration
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang-http-service/api"
+)
+
+func TestLocation_Should_Prefix_Server_Path(t *testing.T) {
+	swagger, err := api.GetSwagger()
+	require.NoError(t, err)
+	routeMiddleware, err := RouteMiddleware(swagger)
+	require.NoError(t, err)
+	var location string
+	h := routeMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		location = Location(r.Context(), "/users/v1/1")
+	}))
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/api/users/v1", nil))
+
+	assert.Equal(t, "/api/users/v1/1", location)
+	assert.Equal(t, "/users/v1/1", Location(context.Background(), "/users/v1/1"))
+}
@@ -0,0 +1,246 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// minLogLevel is given to the underlying handlers, so filtering is done by LevelHandler only
+const minLogLevel = slog.Level(math.MinInt)
+
+// LogLevels keeps root and per logger levels that can be changed at runtime;
+// logger names are hierarchical, e.g. level of `http` applies to `http.access` unless it has its own.
+// Level of every logger in use is resolved once into a slog.LevelVar that is updated on changes,
+// so level checks on log calls do not take locks
+type LogLevels struct {
+	mu        sync.RWMutex
+	baseline  map[string]slog.Level // configured levels, runtime changes revert to them
+	levels    map[string]slog.Level // "" is the root logger
+	vars      map[string]*slog.LevelVar
+	expiresAt map[string]time.Time
+	timers    map[string]*time.Timer
+}
+
+func NewLogLevels(root slog.Level, loggers map[string]slog.Level) *LogLevels {
+	l := &LogLevels{
+		baseline:  map[string]slog.Level{"": root},
+		levels:    map[string]slog.Level{"": root},
+		vars:      make(map[string]*slog.LevelVar),
+		expiresAt: make(map[string]time.Time),
+		timers:    make(map[string]*time.Timer),
+	}
+	for name, level := range loggers {
+		l.baseline[name] = level
+		l.levels[name] = level
+	}
+	return l
+}
+
+// Level returns level of the closest configured logger
+func (l *LogLevels) Level(name string) slog.Level {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.resolve(name)
+}
+
+// LevelVar returns resolved level of the logger that follows runtime changes
+func (l *LogLevels) LevelVar(name string) *slog.LevelVar {
+	l.mu.RLock()
+	v, ok := l.vars[name]
+	l.mu.RUnlock()
+	if ok {
+		return v
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if v, ok := l.vars[name]; ok {
+		return v
+	}
+	v = new(slog.LevelVar)
+	v.Set(l.resolve(name))
+	l.vars[name] = v
+	return v
+}
+
+// resolve walks the logger hierarchy up to the root, callers hold the lock
+func (l *LogLevels) resolve(name string) slog.Level {
+	for {
+		if level, ok := l.levels[name]; ok {
+			return level
+		}
+		idx := strings.LastIndexByte(name, '.')
+		if idx < 0 {
+			return l.levels[""]
+		}
+		name = name[:idx]
+	}
+}
+
+// Set changes logger level, nil level removes logger override; with positive ttl the configured level is restored after it
+func (l *LogLevels) Set(name string, level *slog.Level, ttl time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if timer, ok := l.timers[name]; ok {
+		timer.Stop()
+		delete(l.timers, name)
+		delete(l.expiresAt, name)
+	}
+	l.set(name, level)
+	if ttl > 0 {
+		l.expiresAt[name] = time.Now().Add(ttl)
+		l.timers[name] = time.AfterFunc(ttl, func() {
+			_ = RunRecovered("log level revert", func() error {
+				l.revert(name)
+				return nil
+			})
+		})
+	}
+}
+
+func (l *LogLevels) set(name string, level *slog.Level) {
+	switch {
+	case level != nil:
+		l.levels[name] = *level
+	case name == "":
+		// root level cannot be removed
+		l.levels[name] = l.baseline[name]
+	default:
+		delete(l.levels, name)
+	}
+	for loggerName, v := range l.vars {
+		v.Set(l.resolve(loggerName))
+	}
+}
+
+func (l *LogLevels) revert(name string) {
+	l.mu.Lock()
+	delete(l.timers, name)
+	delete(l.expiresAt, name)
+	if level, ok := l.baseline[name]; ok {
+		l.set(name, &level)
+	} else {
+		l.set(name, nil)
+	}
+	l.mu.Unlock()
+	slog.Info("log level reverted", "logger", name, "level", l.Level(name))
+}
+
+type loggerLevel struct {
+	Name      string     `json:"name"`
+	Level     *string    `json:"level"`
+	TTL       string     `json:"ttl,omitempty"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+// Handler serves `GET /loggers` with current levels and `PUT /loggers` that accepts
+// {"name": "http.access", "level": "WARN", "ttl": "10m"}; empty name is the root logger, null level removes override
+func (l *LogLevels) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /loggers", func(w http.ResponseWriter, r *http.Request) {
+		l.writeLevels(w, r)
+	})
+	mux.HandleFunc("PUT /loggers", func(w http.ResponseWriter, r *http.Request) {
+		var req loggerLevel
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			HandleHTTPBadRequest(w, r, fmt.Errorf("failed to decode request: %w", err))
+			return
+		}
+		var level *slog.Level
+		if req.Level != nil {
+			level = new(slog.Level)
+			if err := level.UnmarshalText([]byte(*req.Level)); err != nil {
+				HandleHTTPBadRequest(w, r, err)
+				return
+			}
+		}
+		var ttl time.Duration
+		if req.TTL != "" {
+			var err error
+			if ttl, err = time.ParseDuration(req.TTL); err != nil {
+				HandleHTTPBadRequest(w, r, fmt.Errorf("failed to parse ttl: %w", err))
+				return
+			}
+		}
+		l.Set(req.Name, level, ttl)
+		slog.InfoContext(r.Context(), "log level changed", "logger", req.Name, "level", req.Level, "ttl", ttl)
+		l.writeLevels(w, r)
+	})
+	return mux
+}
+
+func (l *LogLevels) writeLevels(w http.ResponseWriter, r *http.Request) {
+	l.mu.RLock()
+	loggers := make([]loggerLevel, 0, len(l.levels))
+	for name, level := range l.levels {
+		lvl := level.String()
+		ll := loggerLevel{Name: name, Level: &lvl}
+		if expiresAt, ok := l.expiresAt[name]; ok {
+			ll.ExpiresAt = &expiresAt
+		}
+		loggers = append(loggers, ll)
+	}
+	l.mu.RUnlock()
+	sort.Slice(loggers, func(i, j int) bool { return loggers[i].Name < loggers[j].Name })
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(struct {
+		Loggers []loggerLevel `json:"loggers"`
+	}{Loggers: loggers}); err != nil {
+		slog.ErrorContext(r.Context(), "failed to write loggers to response", "err", err)
+	}
+}
+
+// LevelHandler filters records by level of the named logger from LogLevels
+type LevelHandler struct {
+	next   slog.Handler
+	levels *LogLevels
+	level  *slog.LevelVar
+}
+
+func NewLevelHandler(next slog.Handler, levels *LogLevels) *LevelHandler {
+	return &LevelHandler{next: next, levels: levels, level: levels.LevelVar("")}
+}
+
+func (h *LevelHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.level.Level() && h.next.Enabled(ctx, level)
+}
+
+func (h *LevelHandler) Handle(ctx context.Context, record slog.Record) error {
+	return h.next.Handle(ctx, record)
+}
+
+func (h *LevelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	c := *h
+	c.next = h.next.WithAttrs(attrs)
+	return &c
+}
+
+func (h *LevelHandler) WithGroup(name string) slog.Handler {
+	c := *h
+	c.next = h.next.WithGroup(name)
+	return &c
+}
+
+func (h *LevelHandler) Named(name string) *LevelHandler {
+	c := *h
+	c.level = h.levels.LevelVar(name)
+	c.next = h.next.WithAttrs([]slog.Attr{slog.String("logger", name)})
+	return &c
+}
+
+// NamedLogger returns logger which level can be changed separately via `/loggers` endpoint
+func NamedLogger(name string) *slog.Logger {
+	l := slog.Default()
+	if h, ok := l.Handler().(*LevelHandler); ok {
+		return slog.New(h.Named(name))
+	}
+	return l.With("logger", name)
+}
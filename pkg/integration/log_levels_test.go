@@ -0,0 +1,96 @@
+package integration
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogLevels_Should_Resolve_Hierarchical_Levels(t *testing.T) {
+	levels := NewLogLevels(slog.LevelInfo, map[string]slog.Level{"http": slog.LevelWarn})
+	var buf bytes.Buffer
+	root := NewLevelHandler(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: minLogLevel}), levels)
+
+	slog.New(root.Named("http.access")).Info("filtered")
+	slog.New(root.Named("http.access")).Warn("kept")
+	slog.New(root.Named("otel")).Info("root level")
+	slog.New(root).Debug("filtered")
+
+	assert.NotContains(t, buf.String(), "filtered")
+	assert.Contains(t, buf.String(), "msg=kept logger=http.access")
+	assert.Contains(t, buf.String(), `msg="root level" logger=otel`)
+}
+
+func TestLogLevels_Should_Update_Level_Vars_Of_Existing_Loggers(t *testing.T) {
+	levels := NewLogLevels(slog.LevelInfo, nil)
+	var buf bytes.Buffer
+	access := slog.New(NewLevelHandler(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: minLogLevel}), levels).Named("http.access"))
+	accessVar := levels.LevelVar("http.access")
+	debug := slog.LevelDebug
+
+	levels.Set("http", &debug, 0)
+	access.Debug("kept")
+	assert.Same(t, accessVar, levels.LevelVar("http.access"))
+	assert.Equal(t, slog.LevelDebug, accessVar.Level())
+
+	levels.Set("http", nil, 0)
+	access.Debug("filtered")
+	assert.Equal(t, slog.LevelInfo, accessVar.Level())
+
+	assert.Contains(t, buf.String(), "msg=kept")
+	assert.NotContains(t, buf.String(), "filtered")
+}
+
+func TestLogLevels_Should_Revert_After_TTL(t *testing.T) {
+	levels := NewLogLevels(slog.LevelInfo, nil)
+	debug := slog.LevelDebug
+
+	levels.Set("http", &debug, 10*time.Millisecond)
+	assert.Equal(t, slog.LevelDebug, levels.Level("http.access"))
+
+	assert.Eventually(t, func() bool { return levels.Level("http.access") == slog.LevelInfo }, time.Second, 5*time.Millisecond)
+}
+
+func TestLogLevels_Should_Change_Levels_Via_Endpoint(t *testing.T) {
+	levels := NewLogLevels(slog.LevelInfo, nil)
+	h := levels.Handler()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/loggers", strings.NewReader(`{"name":"http.access","level":"ERROR","ttl":"1h"}`)))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, slog.LevelError, levels.Level("http.access"))
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/loggers", nil))
+	var res struct {
+		Loggers []struct {
+			Name      string     `json:"name"`
+			Level     string     `json:"level"`
+			ExpiresAt *time.Time `json:"expiresAt"`
+		} `json:"loggers"`
+	}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&res))
+	require.Len(t, res.Loggers, 2)
+	assert.Equal(t, "", res.Loggers[0].Name)
+	assert.Equal(t, "INFO", res.Loggers[0].Level)
+	assert.Equal(t, "http.access", res.Loggers[1].Name)
+	assert.Equal(t, "ERROR", res.Loggers[1].Level)
+	assert.NotNil(t, res.Loggers[1].ExpiresAt)
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/loggers", strings.NewReader(`{"name":"http.access","level":null}`)))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, slog.LevelInfo, levels.Level("http.access"))
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/loggers", strings.NewReader(`{"name":"","level":"LOUD"}`)))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
@@ -0,0 +1,77 @@
+// Package logctx carries request scoped log attributes in context; it has no dependencies on the rest of the app,
+// so business code can use it as well
+package logctx
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+)
+
+type holderKey struct{}
+
+// holder is shared by all contexts derived from the request context, so attributes added by inner middlewares
+// are visible to outer ones, e.g. access log gets the subject set after jwt validation
+type holder struct {
+	mu    sync.Mutex
+	attrs []slog.Attr
+}
+
+// NewContext returns context that collects log attributes
+func NewContext(ctx context.Context, attrs ...slog.Attr) context.Context {
+	return context.WithValue(ctx, holderKey{}, &holder{attrs: attrs})
+}
+
+// AddAttrs adds attributes to every log line written with From for the rest of the request;
+// it is a noop for contexts that are not created with NewContext
+func AddAttrs(ctx context.Context, attrs ...slog.Attr) {
+	if h, ok := ctx.Value(holderKey{}).(*holder); ok {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		h.attrs = append(h.attrs, attrs...)
+	}
+}
+
+// Args returns request attributes in a form accepted by slog.Logger.With
+func Args(ctx context.Context) []any {
+	h, ok := ctx.Value(holderKey{}).(*holder)
+	if !ok {
+		return nil
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	args := make([]any, len(h.attrs))
+	for i, a := range h.attrs {
+		args[i] = a
+	}
+	return args
+}
+
+// From returns default logger enriched with request attributes
+func From(ctx context.Context) *slog.Logger {
+	return With(ctx, slog.Default())
+}
+
+// With enriches the given logger with request attributes
+func With(ctx context.Context, l *slog.Logger) *slog.Logger {
+	if args := Args(ctx); len(args) > 0 {
+		return l.With(args...)
+	}
+	return l
+}
+
+// Lookup returns value of the request attribute; the last one wins when the key was added several times
+func Lookup(ctx context.Context, key string) (slog.Value, bool) {
+	h, ok := ctx.Value(holderKey{}).(*holder)
+	if !ok {
+		return slog.Value{}, false
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for i := len(h.attrs) - 1; i >= 0; i-- {
+		if h.attrs[i].Key == key {
+			return h.attrs[i].Value, true
+		}
+	}
+	return slog.Value{}, false
+}
@@ -0,0 +1,31 @@
+package logctx
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogctx_Should_Share_Attrs_With_Parent_Context(t *testing.T) {
+	var buf bytes.Buffer
+	l := slog.New(slog.NewTextHandler(&buf, nil))
+	type childKey struct{}
+	ctx := NewContext(context.Background(), slog.String("requestId", "1"))
+	child := context.WithValue(ctx, childKey{}, true)
+
+	AddAttrs(child, slog.String("subject", "john"))
+	With(ctx, l).Info("done")
+
+	assert.Contains(t, buf.String(), "msg=done requestId=1 subject=john")
+}
+
+func TestLogctx_Should_Ignore_Context_Without_Attrs(t *testing.T) {
+	ctx := context.Background()
+	AddAttrs(ctx, slog.String("subject", "john"))
+
+	assert.Empty(t, Args(ctx))
+	assert.Same(t, slog.Default(), From(ctx))
+}
@@ -0,0 +1,183 @@
+package integration
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"time"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploggrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploghttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/trace"
+	"google.golang.org/grpc/credentials"
+)
+
+// otlpOptions maps the exporter config to options of one signal and protocol, only the option constructors differ
+type otlpOptions[O any] struct {
+	endpointURL func(string) O
+	headers     func(map[string]string) O
+	gzip        O
+	timeout     func(time.Duration) O
+	tls         func(*tls.Config) O
+}
+
+func (o otlpOptions[O]) build(cfg OtlpExporterConfig) ([]O, error) {
+	tlsCfg, err := otlpTLSConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	var opts []O
+	if cfg.Endpoint != "" {
+		opts = append(opts, o.endpointURL(cfg.Endpoint))
+	}
+	if len(cfg.Headers) > 0 {
+		opts = append(opts, o.headers(cfg.Headers))
+	}
+	if cfg.Compression == "gzip" {
+		opts = append(opts, o.gzip)
+	}
+	if cfg.Timeout > 0 {
+		opts = append(opts, o.timeout(cfg.Timeout))
+	}
+	if tlsCfg != nil {
+		opts = append(opts, o.tls(tlsCfg))
+	}
+	return opts, nil
+}
+
+func newOtlpTraceExporter(ctx context.Context, output string, cfg OtlpExporterConfig) (trace.SpanExporter, error) {
+	switch output {
+	case "otlpgrpc":
+		opts, err := otlpOptions[otlptracegrpc.Option]{
+			endpointURL: otlptracegrpc.WithEndpointURL,
+			headers:     otlptracegrpc.WithHeaders,
+			gzip:        otlptracegrpc.WithCompressor("gzip"),
+			timeout:     otlptracegrpc.WithTimeout,
+			tls: func(tlsCfg *tls.Config) otlptracegrpc.Option {
+				return otlptracegrpc.WithTLSCredentials(credentials.NewTLS(tlsCfg))
+			},
+		}.build(cfg)
+		if err != nil {
+			return nil, err
+		}
+		return otlptracegrpc.New(ctx, opts...)
+	case "otlphttp":
+		opts, err := otlpOptions[otlptracehttp.Option]{
+			endpointURL: otlptracehttp.WithEndpointURL,
+			headers:     otlptracehttp.WithHeaders,
+			gzip:        otlptracehttp.WithCompression(otlptracehttp.GzipCompression),
+			timeout:     otlptracehttp.WithTimeout,
+			tls:         otlptracehttp.WithTLSClientConfig,
+		}.build(cfg)
+		if err != nil {
+			return nil, err
+		}
+		return otlptracehttp.New(ctx, opts...)
+	default:
+		return nil, fmt.Errorf("unknown otlp output %s", output)
+	}
+}
+
+func newOtlpMetricExporter(ctx context.Context, output string, cfg OtlpExporterConfig) (metric.Exporter, error) {
+	switch output {
+	case "otlpgrpc":
+		opts, err := otlpOptions[otlpmetricgrpc.Option]{
+			endpointURL: otlpmetricgrpc.WithEndpointURL,
+			headers:     otlpmetricgrpc.WithHeaders,
+			gzip:        otlpmetricgrpc.WithCompressor("gzip"),
+			timeout:     otlpmetricgrpc.WithTimeout,
+			tls: func(tlsCfg *tls.Config) otlpmetricgrpc.Option {
+				return otlpmetricgrpc.WithTLSCredentials(credentials.NewTLS(tlsCfg))
+			},
+		}.build(cfg)
+		if err != nil {
+			return nil, err
+		}
+		return otlpmetricgrpc.New(ctx, opts...)
+	case "otlphttp":
+		opts, err := otlpOptions[otlpmetrichttp.Option]{
+			endpointURL: otlpmetrichttp.WithEndpointURL,
+			headers:     otlpmetrichttp.WithHeaders,
+			gzip:        otlpmetrichttp.WithCompression(otlpmetrichttp.GzipCompression),
+			timeout:     otlpmetrichttp.WithTimeout,
+			tls:         otlpmetrichttp.WithTLSClientConfig,
+		}.build(cfg)
+		if err != nil {
+			return nil, err
+		}
+		return otlpmetrichttp.New(ctx, opts...)
+	default:
+		return nil, fmt.Errorf("unknown otlp output %s", output)
+	}
+}
+
+func newOtlpLogExporter(ctx context.Context, output string, cfg OtlpExporterConfig) (sdklog.Exporter, error) {
+	switch output {
+	case "otlpgrpc":
+		opts, err := otlpOptions[otlploggrpc.Option]{
+			endpointURL: otlploggrpc.WithEndpointURL,
+			headers:     otlploggrpc.WithHeaders,
+			gzip:        otlploggrpc.WithCompressor("gzip"),
+			timeout:     otlploggrpc.WithTimeout,
+			tls: func(tlsCfg *tls.Config) otlploggrpc.Option {
+				return otlploggrpc.WithTLSCredentials(credentials.NewTLS(tlsCfg))
+			},
+		}.build(cfg)
+		if err != nil {
+			return nil, err
+		}
+		return otlploggrpc.New(ctx, opts...)
+	case "otlphttp":
+		opts, err := otlpOptions[otlploghttp.Option]{
+			endpointURL: otlploghttp.WithEndpointURL,
+			headers:     otlploghttp.WithHeaders,
+			gzip:        otlploghttp.WithCompression(otlploghttp.GzipCompression),
+			timeout:     otlploghttp.WithTimeout,
+			tls:         otlploghttp.WithTLSClientConfig,
+		}.build(cfg)
+		if err != nil {
+			return nil, err
+		}
+		return otlploghttp.New(ctx, opts...)
+	default:
+		return nil, fmt.Errorf("unknown otlp output %s", output)
+	}
+}
+
+// otlpTLSConfig returns nil when no TLS settings are configured, so exporters keep their defaults
+func otlpTLSConfig(cfg OtlpExporterConfig) (*tls.Config, error) {
+	if cfg.Tls.CaFile == "" && cfg.Tls.CertFile == "" && !cfg.Tls.InsecureSkipVerify {
+		return nil, nil
+	}
+	tlsCfg := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: cfg.Tls.InsecureSkipVerify, //nolint:gosec // explicitly requested in config
+	}
+	if cfg.Tls.CaFile != "" {
+		ca, err := os.ReadFile(cfg.Tls.CaFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read otlp ca file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("failed to parse otlp ca file %s", cfg.Tls.CaFile)
+		}
+		tlsCfg.RootCAs = pool
+	}
+	if cfg.Tls.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.Tls.CertFile, cfg.Tls.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load otlp client certificate: %w", err)
+		}
+		tlsCfg.Certificates = []tls.Certificate{cert}
+	}
+	return tlsCfg, nil
+}
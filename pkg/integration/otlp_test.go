@@ -0,0 +1,207 @@
+package integration
+
+import (
+	"compress/gzip"
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/contrib/bridges/otelslog"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	"go.opentelemetry.io/otel/sdk/metric"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	colmetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestOtlp_Should_Export_Metrics(t *testing.T) {
+	for _, output := range []string{"otlpgrpc", "otlphttp"} {
+		t.Run(output, func(t *testing.T) {
+			ctx := context.Background()
+			collector := startCollectorStandIn(t)
+
+			exporter, err := newOtlpMetricExporter(ctx, output, OtlpExporterConfig{Endpoint: collector.endpoint(output, "/v1/metrics"), Compression: "gzip"})
+			require.NoError(t, err)
+			mp := metric.NewMeterProvider(metric.WithReader(metric.NewPeriodicReader(exporter)))
+			counter, err := mp.Meter("test").Int64Counter("test_counter")
+			require.NoError(t, err)
+			counter.Add(ctx, 1)
+			require.NoError(t, mp.Shutdown(ctx))
+
+			assert.Contains(t, collector.metricNames(), "test_counter")
+		})
+	}
+}
+
+func TestOtlp_Should_Export_Logs(t *testing.T) {
+	for _, output := range []string{"otlpgrpc", "otlphttp"} {
+		t.Run(output, func(t *testing.T) {
+			ctx := context.Background()
+			collector := startCollectorStandIn(t)
+
+			exporter, err := newOtlpLogExporter(ctx, output, OtlpExporterConfig{Endpoint: collector.endpoint(output, "/v1/logs"), Headers: map[string]string{"x-tenant": "test"}})
+			require.NoError(t, err)
+			lp := sdklog.NewLoggerProvider(sdklog.WithProcessor(sdklog.NewSimpleProcessor(exporter)))
+			log := slog.New(otelslog.NewHandler("golang-http-service", otelslog.WithLoggerProvider(lp)))
+			log.Info("hello", "id", 42)
+			require.NoError(t, lp.Shutdown(ctx))
+
+			bodies, attrs := collector.logRecords()
+			assert.Equal(t, []string{"hello"}, bodies)
+			assert.Equal(t, int64(42), attrs["id"])
+			assert.Equal(t, "test", collector.header("x-tenant"))
+		})
+	}
+}
+
+// collectorStandIn is a minimal OTLP receiver that accepts both grpc and http exports
+type collectorStandIn struct {
+	mu       sync.Mutex
+	grpcAddr string
+	httpURL  string
+	headers  map[string]string
+	logs     []*collogspb.ExportLogsServiceRequest
+	metrics  []*colmetricspb.ExportMetricsServiceRequest
+}
+
+type logsService struct {
+	collogspb.UnimplementedLogsServiceServer
+	c *collectorStandIn
+}
+
+func (s logsService) Export(_ context.Context, req *collogspb.ExportLogsServiceRequest) (*collogspb.ExportLogsServiceResponse, error) {
+	s.c.mu.Lock()
+	defer s.c.mu.Unlock()
+	s.c.logs = append(s.c.logs, req)
+	return &collogspb.ExportLogsServiceResponse{}, nil
+}
+
+type metricsService struct {
+	colmetricspb.UnimplementedMetricsServiceServer
+	c *collectorStandIn
+}
+
+func (s metricsService) Export(_ context.Context, req *colmetricspb.ExportMetricsServiceRequest) (*colmetricspb.ExportMetricsServiceResponse, error) {
+	s.c.mu.Lock()
+	defer s.c.mu.Unlock()
+	s.c.metrics = append(s.c.metrics, req)
+	return &colmetricspb.ExportMetricsServiceResponse{}, nil
+}
+
+func startCollectorStandIn(t *testing.T) *collectorStandIn {
+	c := &collectorStandIn{headers: make(map[string]string)}
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	srv := grpc.NewServer(grpc.UnaryInterceptor(func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		for k, v := range md {
+			c.recordHeader(k, v[0])
+		}
+		return handler(ctx, req)
+	}))
+	collogspb.RegisterLogsServiceServer(srv, logsService{c: c})
+	colmetricspb.RegisterMetricsServiceServer(srv, metricsService{c: c})
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+	c.grpcAddr = lis.Addr().String()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/logs", func(w http.ResponseWriter, r *http.Request) {
+		req := &collogspb.ExportLogsServiceRequest{}
+		c.decodeHTTP(t, r, req)
+		_, _ = logsService{c: c}.Export(r.Context(), req)
+		w.Header().Set("Content-Type", "application/x-protobuf")
+	})
+	mux.HandleFunc("POST /v1/metrics", func(w http.ResponseWriter, r *http.Request) {
+		req := &colmetricspb.ExportMetricsServiceRequest{}
+		c.decodeHTTP(t, r, req)
+		_, _ = metricsService{c: c}.Export(r.Context(), req)
+		w.Header().Set("Content-Type", "application/x-protobuf")
+	})
+	httpSrv := httptest.NewServer(mux)
+	t.Cleanup(httpSrv.Close)
+	c.httpURL = httpSrv.URL
+
+	return c
+}
+
+func (c *collectorStandIn) endpoint(output string, path string) string {
+	if output == "otlpgrpc" {
+		return "http://" + c.grpcAddr
+	}
+	return c.httpURL + path
+}
+
+func (c *collectorStandIn) decodeHTTP(t *testing.T, r *http.Request, msg proto.Message) {
+	var body io.Reader = r.Body
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		gz, err := gzip.NewReader(r.Body)
+		require.NoError(t, err)
+		body = gz
+	}
+	b, err := io.ReadAll(body)
+	require.NoError(t, err)
+	require.NoError(t, proto.Unmarshal(b, msg))
+	for k := range r.Header {
+		c.recordHeader(k, r.Header.Get(k))
+	}
+}
+
+func (c *collectorStandIn) recordHeader(name string, value string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.headers[strings.ToLower(name)] = value
+}
+
+func (c *collectorStandIn) header(name string) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.headers[strings.ToLower(name)]
+}
+
+func (c *collectorStandIn) metricNames() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var names []string
+	for _, req := range c.metrics {
+		for _, rm := range req.GetResourceMetrics() {
+			for _, sm := range rm.GetScopeMetrics() {
+				for _, m := range sm.GetMetrics() {
+					names = append(names, m.GetName())
+				}
+			}
+		}
+	}
+	return names
+}
+
+func (c *collectorStandIn) logRecords() ([]string, map[string]int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var bodies []string
+	attrs := make(map[string]int64)
+	for _, req := range c.logs {
+		for _, rl := range req.GetResourceLogs() {
+			for _, sl := range rl.GetScopeLogs() {
+				for _, lr := range sl.GetLogRecords() {
+					bodies = append(bodies, lr.GetBody().GetStringValue())
+					for _, kv := range lr.GetAttributes() {
+						attrs[kv.GetKey()] = kv.GetValue().GetIntValue()
+					}
+				}
+			}
+		}
+	}
+	return bodies, attrs
+}
@@ -0,0 +1,111 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+	"golang-http-service/pkg/integration/logctx"
+
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+var panicsCounter = sync.OnceValue(func() metric.Int64Counter {
+	counter, err := otel.Meter("golang-http-service").Int64Counter("panics",
+		metric.WithDescription("Recovered panics by operation or background task name"),
+	)
+	if err != nil {
+		otel.Handle(fmt.Errorf("failed to create panics counter: %w", err))
+	}
+	return counter
+})
+
+// recordPanic logs recovered value with stack trace, attaches both to the current span and counts the panic;
+// it must be called from the deferred function that recovered, so the stack includes the panicking frames
+func recordPanic(ctx context.Context, operation string, recovered any) error {
+	stack := string(debug.Stack())
+	err := &PanicError{Value: recovered}
+
+	span := trace.SpanFromContext(ctx)
+	span.RecordError(err, trace.WithAttributes(semconv.ExceptionStacktrace(stack)))
+	span.SetStatus(codes.Error, "panic")
+
+	logctx.From(ctx).ErrorContext(ctx, "panic recovered", "operation", operation, "err", err, "stack", stack)
+
+	if counter := panicsCounter(); counter != nil {
+		counter.Add(context.WithoutCancel(ctx), 1, metric.WithAttributes(attribute.String("operation", operation)))
+	}
+	return err
+}
+
+// PanicError is returned by RunRecovered when the task panicked
+type PanicError struct {
+	Value any
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// RunRecovered runs background task and converts its panic into error, so one failed task does not crash the app
+func RunRecovered(name string, task func() error) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = recordPanic(context.Background(), name, recovered)
+		}
+	}()
+	return task()
+}
+
+var errPanicRecovered = errors.New("internal error occurred")
+
+const (
+	defaultMaxRestarts    = 5
+	defaultRestartWindow  = time.Minute
+	defaultRestartBackoff = 100 * time.Millisecond
+	maxRestartBackoff     = 10 * time.Second
+)
+
+// RestartPolicy protects from crash loops: a task that panics is restarted after backoff that doubles with every
+// restart, more than MaxRestarts panics within Window stop the restarts and the last panic is returned
+type RestartPolicy struct {
+	MaxRestarts int
+	Window      time.Duration
+	Backoff     time.Duration
+}
+
+var DefaultRestartPolicy = RestartPolicy{MaxRestarts: defaultMaxRestarts, Window: defaultRestartWindow, Backoff: defaultRestartBackoff}
+
+// RunSupervised runs background task with RunRecovered and restarts it after panics according to the policy;
+// errors and normal completion of the task are returned as is
+func RunSupervised(name string, task func() error, policy RestartPolicy) error {
+	var panics []time.Time
+	backoff := policy.Backoff
+	for {
+		err := RunRecovered(name, task)
+		var panicErr *PanicError
+		if !errors.As(err, &panicErr) {
+			return err
+		}
+		now := time.Now()
+		panics = append(panics, now)
+		for len(panics) > 0 && now.Sub(panics[0]) > policy.Window {
+			panics = panics[1:]
+		}
+		if len(panics) > policy.MaxRestarts {
+			return fmt.Errorf("%s is crash looping, %d panics within %s: %w", name, len(panics), policy.Window, err)
+		}
+		slog.Warn("restarting background task after panic", "task", name, "backoff", backoff)
+		time.Sleep(backoff)
+		backoff = min(2*backoff, maxRestartBackoff)
+	}
+}
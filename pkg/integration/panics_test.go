@@ -0,0 +1,92 @@
+package integration
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+func TestRecover_Should_Hide_Panic_From_Client_And_Trace_Stack(t *testing.T) {
+	buf := captureDefaultLog(t)
+	recorder := tracetest.NewSpanRecorder()
+	tp := trace.NewTracerProvider(trace.WithSpanProcessor(recorder))
+	h := RecoverMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("secret value")
+	}))
+
+	ctx, span := tp.Tracer("test").Start(context.Background(), "request")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/users/v1", nil).WithContext(ctx))
+	span.End()
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.NotContains(t, rec.Body.String(), "secret value")
+	assert.Contains(t, buf.String(), "panic: secret value")
+	assert.Contains(t, buf.String(), "panics_test.go")
+	require.Len(t, recorder.Ended(), 1)
+	var stack string
+	for _, e := range recorder.Ended()[0].Events() {
+		for _, a := range e.Attributes {
+			if a.Key == "exception.stacktrace" {
+				stack = a.Value.AsString()
+			}
+		}
+	}
+	assert.Contains(t, stack, "panics_test.go")
+}
+
+func TestRecover_Should_Repanic_Abort_Handler(t *testing.T) {
+	h := RecoverMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	})
+}
+
+func TestRecover_Should_Return_Background_Task_Panic_As_Error(t *testing.T) {
+	captureDefaultLog(t)
+
+	err := RunRecovered("task", func() error {
+		var span oteltrace.Span
+		span.End()
+		return nil
+	})
+
+	assert.ErrorContains(t, err, "panic: runtime error: invalid memory address or nil pointer dereference")
+}
+
+func TestRecover_Should_Restart_Panicking_Task_And_Stop_Crash_Loop(t *testing.T) {
+	captureDefaultLog(t)
+	policy := RestartPolicy{MaxRestarts: 2, Window: time.Minute, Backoff: time.Millisecond}
+
+	runs := 0
+	err := RunSupervised("task", func() error {
+		runs++
+		if runs < 3 {
+			panic("flaky")
+		}
+		return nil
+	}, policy)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, runs)
+
+	runs = 0
+	err = RunSupervised("task", func() error {
+		runs++
+		panic("broken")
+	}, policy)
+	var panicErr *PanicError
+	assert.ErrorAs(t, err, &panicErr)
+	assert.ErrorContains(t, err, "task is crash looping, 3 panics within 1m0s")
+	assert.Equal(t, 3, runs)
+}
@@ -3,14 +3,31 @@ package integration
 import (
 	"fmt"
 	"net/http"
+	"regexp"
+	"sort"
+	"strings"
 	"time"
 
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"golang-http-service/api/petstore"
 )
 
 func CreatePetStoreAPIClient(url string) (petstore.ClientWithResponsesInterface, error) {
-	transport := otelhttp.NewTransport(http.DefaultTransport)
+	operations, err := petstoreOperations()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read petstore operations: %w", err)
+	}
+	transport := otelhttp.NewTransport(
+		&clientOperationTransport{next: http.DefaultTransport, operations: operations},
+		otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+			if op, _ := operations.match(r); op != nil {
+				return "petstore." + op.id
+			}
+			return "petstore." + r.Method
+		}),
+	)
 	httpClient := &http.Client{Timeout: time.Minute, Transport: transport}
 	apiClient, err := petstore.NewClientWithResponses(url, petstore.WithHTTPClient(httpClient))
 	if err != nil {
@@ -18,3 +35,70 @@ func CreatePetStoreAPIClient(url string) (petstore.ClientWithResponsesInterface,
 	}
 	return apiClient, nil
 }
+
+type clientOperation struct {
+	id     string
+	method string
+	path   *regexp.Regexp
+	params []string
+}
+
+type clientOperations []clientOperation
+
+// petstoreOperations builds matchers for the spec paths, so client spans are named after openapi operations
+func petstoreOperations() (clientOperations, error) {
+	swagger, err := petstore.GetSwagger()
+	if err != nil {
+		return nil, err
+	}
+	paramRe := regexp.MustCompile(`\{([^}]+)}`)
+	var ops clientOperations
+	for template, path := range swagger.Paths.Map() {
+		var params []string
+		pattern := regexp.QuoteMeta(template)
+		for _, m := range paramRe.FindAllStringSubmatch(template, -1) {
+			params = append(params, m[1])
+			pattern = strings.Replace(pattern, regexp.QuoteMeta(m[0]), `([^/]+)`, 1)
+		}
+		// server url is not known in advance, so templates are matched against the end of request path
+		re, err := regexp.Compile(pattern + `$`)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compile %s path: %w", template, err)
+		}
+		for method, op := range path.Operations() {
+			ops = append(ops, clientOperation{id: op.OperationID, method: method, path: re, params: params})
+		}
+	}
+	// static paths go first, e.g. /pet/findByStatus should not be matched as /pet/{petId}
+	sort.SliceStable(ops, func(i, j int) bool { return len(ops[i].params) < len(ops[j].params) })
+	return ops, nil
+}
+
+func (ops clientOperations) match(r *http.Request) (*clientOperation, []string) {
+	for i := range ops {
+		if ops[i].method != r.Method {
+			continue
+		}
+		if m := ops[i].path.FindStringSubmatch(r.URL.Path); m != nil {
+			return &ops[i], m[1:]
+		}
+	}
+	return nil, nil
+}
+
+// clientOperationTransport runs inside otelhttp transport and labels client span with operation id and path params
+type clientOperationTransport struct {
+	next       http.RoundTripper
+	operations clientOperations
+}
+
+func (t *clientOperationTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	if op, values := t.operations.match(r); op != nil {
+		attrs := []attribute.KeyValue{attribute.String("rpc.method", op.id)}
+		for i, param := range op.params {
+			attrs = append(attrs, attribute.String("petstore."+param, values[i]))
+		}
+		trace.SpanFromContext(r.Context()).SetAttributes(attrs...)
+	}
+	return t.next.RoundTrip(r)
+}
@@ -0,0 +1,45 @@
+package integration
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestPetStore_Should_Name_Client_Spans_After_Operations(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(trace.NewTracerProvider(trace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if strings.HasSuffix(r.URL.Path, "/findByStatus") {
+			_, _ = w.Write([]byte(`[]`))
+			return
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	client, err := CreatePetStoreAPIClient(srv.URL + "/api/v3")
+	require.NoError(t, err)
+	_, err = client.GetPetByIdWithResponse(context.Background(), 42)
+	require.NoError(t, err)
+	_, err = client.FindPetsByStatusWithResponse(context.Background(), nil)
+	require.NoError(t, err)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	assert.Equal(t, "petstore.GetPetById", spans[0].Name())
+	assert.Contains(t, spans[0].Attributes(), attribute.String("petstore.petId", "42"))
+	assert.Equal(t, "petstore.FindPetsByStatus", spans[1].Name())
+}
@@ -0,0 +1,156 @@
+package integration
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"golang-http-service/api"
+)
+
+const problemTypesDocs = "https://github.com/slamdev/golang-http-service#problem-types"
+
+// ProblemType is an entry of problem types catalog, see RFC 9457;
+// the catalog is published in `x-problem-types` of openapi components
+type ProblemType struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Docs   string `json:"docs"`
+}
+
+var (
+	InvalidRequestProblem = ProblemType{
+		Type:   "urn:problem-type:users-api:invalid-request",
+		Title:  "Invalid Request",
+		Status: http.StatusBadRequest,
+		Docs:   problemTypesDocs,
+	}
+	ValidationProblem = ProblemType{
+		Type:   "urn:problem-type:users-api:validation",
+		Title:  "Validation Failed",
+		Status: http.StatusBadRequest,
+		Docs:   problemTypesDocs,
+	}
+	MissingEntityProblem = ProblemType{
+		Type:   "urn:problem-type:users-api:missing-entity",
+		Title:  "Entity Not Found",
+		Status: http.StatusNotFound,
+		Docs:   problemTypesDocs,
+	}
+	IdempotencyInProgressProblem = ProblemType{
+		Type:   "urn:problem-type:users-api:idempotency-in-progress",
+		Title:  "Idempotent Request In Progress",
+		Status: http.StatusConflict,
+		Docs:   problemTypesDocs,
+	}
+	IdempotencyKeyReusedProblem = ProblemType{
+		Type:   "urn:problem-type:users-api:idempotency-key-reused",
+		Title:  "Idempotency Key Reused",
+		Status: http.StatusUnprocessableEntity,
+		Docs:   problemTypesDocs,
+	}
+	VersionMismatchProblem = ProblemType{
+		Type:   "urn:problem-type:users-api:version-mismatch",
+		Title:  "Version Mismatch",
+		Status: http.StatusPreconditionFailed,
+		Docs:   problemTypesDocs,
+	}
+)
+
+// FieldError describes why value of a request body field, given by dotted path, is invalid
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+// Problem assigns a problem type of the catalog and field errors to the error it wraps;
+// domain errors are mapped to problems by the boundary, so this package does not depend on the domain
+type Problem struct {
+	Type   ProblemType
+	Fields []FieldError
+	err    error
+}
+
+func NewProblem(problemType ProblemType, err error, fields ...FieldError) *Problem {
+	return &Problem{Type: problemType, Fields: fields, err: err}
+}
+
+func (p *Problem) Error() string {
+	return p.err.Error()
+}
+
+func (p *Problem) Unwrap() error {
+	return p.err
+}
+
+func isProblem(problemType ProblemType) func(error) bool {
+	return func(err error) bool {
+		var problem *Problem
+		return errors.As(err, &problem) && problem.Type == problemType
+	}
+}
+
+// problemTypes maps errors to problem types, the first match with the response status wins
+var problemTypes = []struct {
+	match       func(error) bool
+	problemType ProblemType
+}{
+	{match: IsRequestValidationError, problemType: InvalidRequestProblem},
+	{match: isProblem(ValidationProblem), problemType: ValidationProblem},
+	{match: isProblem(MissingEntityProblem), problemType: MissingEntityProblem},
+	{match: isIdempotencyInProgress, problemType: IdempotencyInProgressProblem},
+	{match: isIdempotencyKeyReused, problemType: IdempotencyKeyReusedProblem},
+	{match: isProblem(VersionMismatchProblem), problemType: VersionMismatchProblem},
+}
+
+// ProblemTypes returns the catalog of problem types
+func ProblemTypes() []ProblemType {
+	catalog := make([]ProblemType, len(problemTypes))
+	for i, pt := range problemTypes {
+		catalog[i] = pt.problemType
+	}
+	return catalog
+}
+
+// problemTypeOf resolves problem type of the error; errors that are not in the catalog
+// get `about:blank` type with the status text as title
+func problemTypeOf(status int, err error) ProblemType {
+	if err != nil {
+		for _, pt := range problemTypes {
+			if pt.problemType.Status == status && pt.match(err) {
+				return pt.problemType
+			}
+		}
+	}
+	return ProblemType{Type: "about:blank", Title: http.StatusText(status), Status: status}
+}
+
+// problemFieldErrors converts field errors of the problem to `errors` extension member
+func problemFieldErrors(err error, redactor *Redactor) *[]api.ProblemFieldError {
+	var problem *Problem
+	if !errors.As(err, &problem) || len(problem.Fields) == 0 {
+		return nil
+	}
+	fieldErrors := make([]api.ProblemFieldError, len(problem.Fields))
+	for i, f := range problem.Fields {
+		fieldErrors[i] = api.ProblemFieldError{
+			Detail:  redactor.String(f.Message),
+			Pointer: fieldPointer(f.Field),
+		}
+	}
+	return &fieldErrors
+}
+
+// fieldPointer converts dotted field path, e.g. `address.city`, to JSON pointer of the request body
+func fieldPointer(field string) string {
+	return jsonPointer(strings.Split(field, "."))
+}
+
+func jsonPointer(segments []string) string {
+	escaped := make([]string, len(segments))
+	for i, s := range segments {
+		escaped[i] = strings.NewReplacer("~", "~0", "/", "~1").Replace(s)
+	}
+	return "#/" + strings.Join(escaped, "/")
+}
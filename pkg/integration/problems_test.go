@@ -0,0 +1,59 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang-http-service/api"
+)
+
+func TestProblemTypes_Should_Match_OpenAPI_Catalog(t *testing.T) {
+	swagger, err := api.GetSwagger()
+	require.NoError(t, err)
+
+	raw, err := json.Marshal(swagger.Components.Extensions["x-problem-types"])
+	require.NoError(t, err)
+	var catalog []ProblemType
+	require.NoError(t, json.Unmarshal(raw, &catalog))
+
+	assert.Equal(t, ProblemTypes(), catalog)
+}
+
+func TestProblemDetail_Should_Map_Problems_To_Problem_Types(t *testing.T) {
+	ctx := context.Background()
+	err := NewProblem(ValidationProblem, errors.New("user with name bob already exists"),
+		FieldError{Field: "name", Message: "is already taken"})
+
+	p := createAndRecordProblemDetail(ctx, http.StatusBadRequest, err)
+	assert.Equal(t, ValidationProblem.Type, p.Type)
+	assert.Equal(t, ValidationProblem.Title, p.Title)
+	require.NotNil(t, p.Errors)
+	assert.Equal(t, []api.ProblemFieldError{{Detail: "is already taken", Pointer: "#/name"}}, *p.Errors)
+
+	p = createAndRecordProblemDetail(ctx, http.StatusNotFound, NewProblem(MissingEntityProblem, errors.New("user 1 not found")))
+	assert.Equal(t, MissingEntityProblem.Type, p.Type)
+	assert.Nil(t, p.Errors)
+
+	p = createAndRecordProblemDetail(ctx, http.StatusUnauthorized, errors.New("token expired"))
+	assert.Equal(t, "about:blank", p.Type)
+	assert.Equal(t, http.StatusText(http.StatusUnauthorized), p.Title)
+}
+
+func TestProblemDetail_Should_Marshal_Field_Errors(t *testing.T) {
+	p := createAndRecordProblemDetail(context.Background(), http.StatusBadRequest,
+		NewProblem(ValidationProblem, errors.New("invalid address"), FieldError{Field: "address.zip/code", Message: "is too long"}))
+
+	data, err := json.Marshal(p)
+	require.NoError(t, err)
+	var decoded api.ProblemDetail
+	require.NoError(t, json.Unmarshal(data, &decoded))
+
+	require.NotNil(t, decoded.Errors)
+	assert.Equal(t, "#/address/zip~1code", (*decoded.Errors)[0].Pointer)
+	assert.Equal(t, "invalid address", decoded.Detail.Error())
+}
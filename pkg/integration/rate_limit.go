@@ -0,0 +1,202 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"golang-http-service/pkg/integration/logctx"
+)
+
+const (
+	rateLimitExtension     = "x-rate-limit"
+	rateLimitSweepInterval = time.Minute
+)
+
+// RateLimit allows Requests per Period for each client, up to Requests can be made in a burst
+//
+//	x-rate-limit:
+//	  requests: 10
+//	  period: 1s
+type RateLimit struct {
+	Requests int
+	Period   time.Duration
+}
+
+func (l RateLimit) enabled() bool {
+	return l.Requests > 0 && l.Period > 0
+}
+
+type RateLimitResult struct {
+	Allowed    bool
+	Remaining  int
+	Reset      time.Duration // until the bucket is full again
+	RetryAfter time.Duration // until the next token, set when request is not allowed
+}
+
+// RateLimitStore keeps token buckets by key; MemoryRateLimitStore limits requests per instance,
+// a store backed by shared storage (e.g. redis) makes limits global for all instances
+type RateLimitStore interface {
+	Take(ctx context.Context, key string, limit RateLimit) (RateLimitResult, error)
+}
+
+// RateLimitMiddleware limits requests per operation and client; client is JWT subject or IP address.
+// Operation limits come from config, then from x-rate-limit extension of the spec, then the default limit.
+// Store failures do not block requests
+func RateLimitMiddleware(swagger *openapi3.T, cfg RateLimitConfig, store RateLimitStore) (func(next http.Handler) http.Handler, error) {
+	limits, err := OperationRateLimits(swagger)
+	if err != nil {
+		return nil, err
+	}
+	for op, limit := range cfg.Operations {
+		limits[strings.ToLower(op)] = limit
+	}
+	limited, err := otel.Meter("golang-http-service").Int64Counter("http_server_rate_limited_requests",
+		metric.WithDescription("Requests rejected by rate limiter per openapi operation"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create rate limited requests counter: %w", err)
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			operation := OperationIDFromContext(r.Context())
+			limit, ok := limits[strings.ToLower(operation)]
+			if !ok {
+				limit = cfg.Default
+			}
+			if !limit.enabled() {
+				next.ServeHTTP(w, r)
+				return
+			}
+			key := operation + "|" + clientIdentity(r)
+			res, err := store.Take(r.Context(), key, limit)
+			if err != nil {
+				logctx.From(r.Context()).WarnContext(r.Context(), "rate limit store failed, request is allowed", "err", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+			header := w.Header()
+			header.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", limit.Requests, ceilSeconds(limit.Period)))
+			header.Set("RateLimit-Limit", strconv.Itoa(limit.Requests))
+			header.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+			header.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(res.Reset)))
+			if !res.Allowed {
+				limited.Add(context.WithoutCancel(r.Context()), 1, metric.WithAttributes(attribute.String("operation", operation)))
+				header.Set("Retry-After", strconv.Itoa(ceilSeconds(res.RetryAfter)))
+				HandleHTTPTooManyRequests(w, r, fmt.Errorf("rate limit of %d requests per %s is exceeded", limit.Requests, limit.Period))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}, nil
+}
+
+// OperationRateLimits reads x-rate-limit extensions from the spec, keys are lower case operation ids
+func OperationRateLimits(swagger *openapi3.T) (map[string]RateLimit, error) {
+	limits := make(map[string]RateLimit)
+	for _, path := range swagger.Paths.Map() {
+		for _, op := range path.Operations() {
+			ext, ok := op.Extensions[rateLimitExtension]
+			if !ok {
+				continue
+			}
+			limit, err := parseRateLimit(ext)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse %s of %s operation: %w", rateLimitExtension, op.OperationID, err)
+			}
+			limits[strings.ToLower(op.OperationID)] = limit
+		}
+	}
+	return limits, nil
+}
+
+func parseRateLimit(ext any) (RateLimit, error) {
+	raw, err := json.Marshal(ext)
+	if err != nil {
+		return RateLimit{}, err
+	}
+	var limit struct {
+		Requests int    `json:"requests"`
+		Period   string `json:"period"`
+	}
+	if err := json.Unmarshal(raw, &limit); err != nil {
+		return RateLimit{}, err
+	}
+	period, err := time.ParseDuration(limit.Period)
+	if err != nil {
+		return RateLimit{}, err
+	}
+	if limit.Requests <= 0 || period <= 0 {
+		return RateLimit{}, fmt.Errorf("requests and period have to be positive, got %d per %s", limit.Requests, period)
+	}
+	return RateLimit{Requests: limit.Requests, Period: period}, nil
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// MemoryRateLimitStore keeps token buckets in memory; buckets that are full again are removed periodically
+type MemoryRateLimitStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+type tokenBucket struct {
+	tokens   float64
+	lastTick time.Time
+	limit    RateLimit
+}
+
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{buckets: make(map[string]*tokenBucket), lastSweep: time.Now(), now: time.Now}
+}
+
+func (s *MemoryRateLimitStore) Take(_ context.Context, key string, limit RateLimit) (RateLimitResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	if now.Sub(s.lastSweep) > rateLimitSweepInterval {
+		s.sweep(now)
+	}
+	capacity := float64(limit.Requests)
+	perToken := limit.Period / time.Duration(limit.Requests)
+	b, ok := s.buckets[key]
+	if !ok || b.limit != limit {
+		b = &tokenBucket{tokens: capacity, lastTick: now, limit: limit}
+		s.buckets[key] = b
+	}
+	b.tokens = min(capacity, b.tokens+float64(now.Sub(b.lastTick))/float64(perToken))
+	b.lastTick = now
+
+	res := RateLimitResult{Allowed: b.tokens >= 1}
+	if res.Allowed {
+		b.tokens--
+	} else {
+		res.RetryAfter = time.Duration((1 - b.tokens) * float64(perToken))
+	}
+	res.Remaining = int(b.tokens)
+	res.Reset = time.Duration((capacity - b.tokens) * float64(perToken))
+	return res, nil
+}
+
+func (s *MemoryRateLimitStore) sweep(now time.Time) {
+	for key, b := range s.buckets {
+		if now.Sub(b.lastTick) >= b.limit.Period {
+			delete(s.buckets, key)
+		}
+	}
+	s.lastSweep = now
+}
@@ -0,0 +1,149 @@
+package integration
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	jwtmiddleware "github.com/auth0/go-jwt-middleware/v2"
+	"github.com/auth0/go-jwt-middleware/v2/validator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang-http-service/api"
+)
+
+func TestMemoryRateLimitStore_Should_Refill_Tokens(t *testing.T) {
+	now := time.Now()
+	store := NewMemoryRateLimitStore()
+	store.now = func() time.Time { return now }
+	limit := RateLimit{Requests: 2, Period: time.Second}
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		res, err := store.Take(ctx, "k", limit)
+		require.NoError(t, err)
+		assert.True(t, res.Allowed)
+	}
+	res, _ := store.Take(ctx, "k", limit)
+	assert.False(t, res.Allowed)
+	assert.Equal(t, 500*time.Millisecond, res.RetryAfter)
+
+	now = now.Add(500 * time.Millisecond)
+	res, _ = store.Take(ctx, "k", limit)
+	assert.True(t, res.Allowed)
+	assert.Equal(t, 0, res.Remaining)
+
+	res, _ = store.Take(ctx, "other", limit)
+	assert.True(t, res.Allowed)
+	assert.Equal(t, 1, res.Remaining)
+}
+
+func TestMemoryRateLimitStore_Should_Sweep_Full_Buckets(t *testing.T) {
+	now := time.Now()
+	store := NewMemoryRateLimitStore()
+	store.now = func() time.Time { return now }
+	_, _ = store.Take(context.Background(), "k", RateLimit{Requests: 1, Period: time.Second})
+
+	now = now.Add(2 * rateLimitSweepInterval)
+	_, _ = store.Take(context.Background(), "other", RateLimit{Requests: 1, Period: time.Second})
+
+	assert.NotContains(t, store.buckets, "k")
+}
+
+func rateLimitedHandler(t *testing.T, cfg RateLimitConfig) http.Handler {
+	swagger, err := api.GetSwagger()
+	require.NoError(t, err)
+	routeMiddleware, err := RouteMiddleware(swagger)
+	require.NoError(t, err)
+	rateLimit, err := RateLimitMiddleware(swagger, cfg, NewMemoryRateLimitStore())
+	require.NoError(t, err)
+	return routeMiddleware(rateLimit(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})))
+}
+
+func TestRateLimit_Should_Reject_With_Headers(t *testing.T) {
+	h := rateLimitedHandler(t, RateLimitConfig{Operations: map[string]RateLimit{"getUsers": {Requests: 1, Period: time.Minute}}})
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/users/v1", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "1", rec.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "0", rec.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "1;w=60", rec.Header().Get("RateLimit-Policy"))
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/users/v1", nil))
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "60", rec.Header().Get("Retry-After"))
+	assert.Equal(t, "application/problem+json", rec.Header().Get("Content-Type"))
+}
+
+func TestRateLimit_Should_Limit_Clients_Separately(t *testing.T) {
+	h := rateLimitedHandler(t, RateLimitConfig{Default: RateLimit{Requests: 1, Period: time.Minute}})
+	withSubject := func(subject string) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/api/users/v1/1", nil)
+		claims := &validator.ValidatedClaims{RegisteredClaims: validator.RegisteredClaims{Subject: subject}}
+		return r.WithContext(context.WithValue(r.Context(), jwtmiddleware.ContextKey{}, claims))
+	}
+
+	for _, r := range []*http.Request{withSubject("alice"), withSubject("bob"), httptest.NewRequest(http.MethodGet, "/api/users/v1/1", nil)} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, r)
+		assert.Equal(t, http.StatusOK, rec.Code)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, withSubject("alice"))
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+}
+
+func TestRateLimit_Should_Not_Identify_Clients_By_Unverified_Headers(t *testing.T) {
+	h := rateLimitedHandler(t, RateLimitConfig{Default: RateLimit{Requests: 1, Period: time.Minute}})
+	withAPIKey := func(key string) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/api/users/v1/1", nil)
+		r.Header.Set("X-Api-Key", key)
+		return r
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, withAPIKey("first"))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, withAPIKey("second"))
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+}
+
+func TestRateLimit_Should_Ignore_Spoofed_Forwarded_For_Entries(t *testing.T) {
+	h := ClientAddressMiddleware(true)(rateLimitedHandler(t, RateLimitConfig{Default: RateLimit{Requests: 1, Period: time.Minute}}))
+	forwardedFor := func(values ...string) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/api/users/v1/1", nil)
+		for _, v := range values {
+			r.Header.Add("X-Forwarded-For", v)
+		}
+		return r
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, forwardedFor("10.0.0.1, 203.0.113.7"))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	for _, r := range []*http.Request{forwardedFor("10.0.0.2, 203.0.113.7"), forwardedFor("10.0.0.3", "203.0.113.7")} {
+		rec = httptest.NewRecorder()
+		h.ServeHTTP(rec, r)
+		assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	}
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, forwardedFor("10.0.0.1, 203.0.113.8"))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestRateLimit_Should_Read_Limits_From_Spec(t *testing.T) {
+	swagger, err := api.GetSwagger()
+	require.NoError(t, err)
+
+	limits, err := OperationRateLimits(swagger)
+
+	require.NoError(t, err)
+	assert.Equal(t, map[string]RateLimit{"createuser": {Requests: 10, Period: time.Second}}, limits)
+}
@@ -0,0 +1,289 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/felixge/httpsnoop"
+	"github.com/getkin/kin-openapi/openapi3"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultDurationBuckets are used for operation duration histogram when buckets are not configured, in seconds
+var DefaultDurationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// SLO is declared per operation in openapi spec with x-slo extension, e.g.
+//
+//	x-slo:
+//	  availability: 0.999 # share of requests that should not fail with 5xx
+//	  latency: 250ms      # has to match one of the duration buckets
+//	  latencyTarget: 0.99 # share of requests that should be faster than latency
+type SLO struct {
+	Availability  float64       `json:"availability"`
+	Latency       time.Duration `json:"-"`
+	LatencyTarget float64       `json:"latencyTarget"`
+}
+
+const sloExtension = "x-slo"
+
+// redLabels is filled in by inner middlewares, e.g. auth role is known only after jwt is validated
+type redLabels struct {
+	role string
+}
+
+type redLabelsKey struct{}
+
+// RedMetricsMiddleware records request rate, errors and duration per openapi operation, status class and auth role
+func RedMetricsMiddleware(swagger *openapi3.T, durationBuckets []float64) (func(next http.Handler) http.Handler, error) {
+	if len(durationBuckets) == 0 {
+		durationBuckets = DefaultDurationBuckets
+	}
+	if _, err := OperationSLOs(swagger, durationBuckets); err != nil {
+		return nil, err
+	}
+
+	meter := otel.Meter("golang-http-service")
+	requests, err := meter.Int64Counter("http_server_operation_requests",
+		metric.WithDescription("Requests handled per openapi operation"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create requests counter: %w", err)
+	}
+	errs, err := meter.Int64Counter("http_server_operation_errors",
+		metric.WithDescription("Requests per openapi operation that failed with 5xx status"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create errors counter: %w", err)
+	}
+	duration, err := meter.Float64Histogram("http_server_operation_duration",
+		metric.WithDescription("Duration of requests per openapi operation"),
+		metric.WithUnit("s"),
+		metric.WithExplicitBucketBoundaries(durationBuckets...),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create duration histogram: %w", err)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			labels := &redLabels{}
+			r = r.WithContext(context.WithValue(r.Context(), redLabelsKey{}, labels))
+			m := httpsnoop.CaptureMetrics(next, w, r)
+
+			operation := OperationIDFromContext(r.Context())
+			if operation == "" {
+				operation = unknownOperation
+			}
+			role := labels.role
+			if role == "" {
+				role = "anonymous"
+			}
+			attrs := metric.WithAttributes(
+				attribute.String("operation", operation),
+				attribute.String("status_class", fmt.Sprintf("%dxx", m.Code/100)),
+				attribute.String("role", role),
+			)
+			ctx := context.WithoutCancel(r.Context())
+			requests.Add(ctx, 1, attrs)
+			if m.Code >= http.StatusInternalServerError {
+				errs.Add(ctx, 1, attrs)
+			}
+			duration.Record(ctx, m.Duration.Seconds(), attrs)
+		})
+	}, nil
+}
+
+func setRedRole(ctx context.Context, roles []string) {
+	if labels, ok := ctx.Value(redLabelsKey{}).(*redLabels); ok {
+		sorted := slices.Clone(roles)
+		slices.Sort(sorted)
+		labels.role = strings.Join(sorted, ",")
+	}
+}
+
+// OperationSLOs reads x-slo extensions from the spec; latency has to be one of the buckets,
+// otherwise it cannot be queried from the histogram
+func OperationSLOs(swagger *openapi3.T, durationBuckets []float64) (map[string]SLO, error) {
+	slos := make(map[string]SLO)
+	for _, path := range swagger.Paths.Map() {
+		for _, op := range path.Operations() {
+			ext, ok := op.Extensions[sloExtension]
+			if !ok {
+				continue
+			}
+			slo, err := parseSLO(ext)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse %s of %s operation: %w", sloExtension, op.OperationID, err)
+			}
+			if slo.Latency > 0 && !slices.Contains(durationBuckets, slo.Latency.Seconds()) {
+				return nil, fmt.Errorf("latency %s of %s operation is not one of duration buckets %v", slo.Latency, op.OperationID, durationBuckets)
+			}
+			slos[op.OperationID] = slo
+		}
+	}
+	return slos, nil
+}
+
+func parseSLO(ext any) (SLO, error) {
+	raw, err := json.Marshal(ext)
+	if err != nil {
+		return SLO{}, err
+	}
+	var slo struct {
+		SLO
+		Latency string `json:"latency"`
+	}
+	if err := json.Unmarshal(raw, &slo); err != nil {
+		return SLO{}, err
+	}
+	if slo.Latency != "" {
+		if slo.SLO.Latency, err = time.ParseDuration(slo.Latency); err != nil {
+			return SLO{}, err
+		}
+	}
+	return slo.SLO, nil
+}
+
+type ruleGroups struct {
+	Groups []ruleGroup `yaml:"groups"`
+}
+
+type ruleGroup struct {
+	Name  string `yaml:"name"`
+	Rules []rule `yaml:"rules"`
+}
+
+type rule struct {
+	Record      string            `yaml:"record,omitempty"`
+	Alert       string            `yaml:"alert,omitempty"`
+	Expr        string            `yaml:"expr"`
+	Labels      map[string]string `yaml:"labels,omitempty"`
+	Annotations map[string]string `yaml:"annotations,omitempty"`
+}
+
+// sloWindows are pairs of long and short windows with burn rate thresholds,
+// see https://sre.google/workbook/alerting-on-slos/#6-multiwindow-multi-burn-rate-alerts
+var sloWindows = []struct {
+	long     string
+	short    string
+	burnRate float64
+	severity string
+}{
+	{long: "1h", short: "5m", burnRate: 14.4, severity: "critical"},
+	{long: "6h", short: "30m", burnRate: 6, severity: "warning"},
+}
+
+// SLORules renders prometheus recording and alerting rules for operations with x-slo extension
+func SLORules(swagger *openapi3.T, durationBuckets []float64) ([]byte, error) {
+	if len(durationBuckets) == 0 {
+		durationBuckets = DefaultDurationBuckets
+	}
+	slos, err := OperationSLOs(swagger, durationBuckets)
+	if err != nil {
+		return nil, err
+	}
+	operations := make([]string, 0, len(slos))
+	for op := range slos {
+		operations = append(operations, op)
+	}
+	sort.Strings(operations)
+
+	var windows []string
+	for _, w := range sloWindows {
+		windows = append(windows, w.short, w.long)
+	}
+	slices.Sort(windows)
+	windows = slices.Compact(windows)
+
+	recording := ruleGroup{Name: "golang-http-service-sli"}
+	for _, window := range windows {
+		recording.Rules = append(recording.Rules,
+			rule{
+				Record: "operation:http_server_operation_requests:rate" + window,
+				Expr:   fmt.Sprintf("sum by (operation) (rate(http_server_operation_requests_total[%s]))", window),
+			},
+			rule{
+				Record: "operation:http_server_operation_errors:ratio_rate" + window,
+				Expr: fmt.Sprintf("sum by (operation) (rate(http_server_operation_errors_total[%s]))\n/\n"+
+					"sum by (operation) (rate(http_server_operation_requests_total[%s]))", window, window),
+			},
+		)
+		for _, op := range operations {
+			if slos[op].Latency == 0 {
+				continue
+			}
+			recording.Rules = append(recording.Rules, rule{
+				Record: "operation:http_server_operation_slow:ratio_rate" + window,
+				Expr: fmt.Sprintf("1 - (\n"+
+					"sum(rate(http_server_operation_duration_seconds_bucket{operation=%q,le=~%q}[%s]))\n/\n"+
+					"sum(rate(http_server_operation_duration_seconds_count{operation=%q}[%s]))\n)",
+					op, bucketPattern(slos[op].Latency), window, op, window),
+				Labels: map[string]string{"operation": op},
+			})
+		}
+	}
+
+	alerting := ruleGroup{Name: "golang-http-service-slo"}
+	for _, op := range operations {
+		slo := slos[op]
+		for _, w := range sloWindows {
+			labels := map[string]string{"severity": w.severity}
+			if slo.Availability > 0 {
+				threshold := w.burnRate * (1 - slo.Availability)
+				alerting.Rules = append(alerting.Rules, rule{
+					Alert: "OperationErrorBudgetBurn",
+					Expr: fmt.Sprintf("operation:http_server_operation_errors:ratio_rate%s{operation=%q} > %s\nand\n"+
+						"operation:http_server_operation_errors:ratio_rate%s{operation=%q} > %s",
+						w.long, op, formatFloat(threshold), w.short, op, formatFloat(threshold)),
+					Labels: labels,
+					Annotations: map[string]string{
+						"summary": fmt.Sprintf("%s operation burns availability error budget %gx faster than allowed by %g SLO", op, w.burnRate, slo.Availability),
+					},
+				})
+			}
+			if slo.Latency > 0 && slo.LatencyTarget > 0 {
+				threshold := w.burnRate * (1 - slo.LatencyTarget)
+				alerting.Rules = append(alerting.Rules, rule{
+					Alert: "OperationLatencyBudgetBurn",
+					Expr: fmt.Sprintf("operation:http_server_operation_slow:ratio_rate%s{operation=%q} > %s\nand\n"+
+						"operation:http_server_operation_slow:ratio_rate%s{operation=%q} > %s",
+						w.long, op, formatFloat(threshold), w.short, op, formatFloat(threshold)),
+					Labels: labels,
+					Annotations: map[string]string{
+						"summary": fmt.Sprintf("%s operation burns latency error budget %gx faster than allowed by %g of requests under %s SLO", op, w.burnRate, slo.LatencyTarget, slo.Latency),
+					},
+				})
+			}
+		}
+	}
+
+	out, err := yaml.Marshal(ruleGroups{Groups: []ruleGroup{recording, alerting}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal rules: %w", err)
+	}
+	return out, nil
+}
+
+// bucketPattern matches le label in both text (0.25, 1) and OpenMetrics (0.25, 1.0) exposition formats
+func bucketPattern(d time.Duration) string {
+	le := strconv.FormatFloat(d.Seconds(), 'f', -1, 64)
+	if !strings.Contains(le, ".") {
+		return regexp.QuoteMeta(le) + `(\.0)?`
+	}
+	return regexp.QuoteMeta(le)
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', 6, 64)
+}
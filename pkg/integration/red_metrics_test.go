@@ -0,0 +1,76 @@
+package integration
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"golang-http-service/api"
+)
+
+func TestRedMetrics_Should_Record_Per_Operation(t *testing.T) {
+	reader := metric.NewManualReader()
+	prev := otel.GetMeterProvider()
+	otel.SetMeterProvider(metric.NewMeterProvider(metric.WithReader(reader)))
+	t.Cleanup(func() { otel.SetMeterProvider(prev) })
+
+	swagger, err := api.GetSwagger()
+	require.NoError(t, err)
+	routeMiddleware, err := RouteMiddleware(swagger)
+	require.NoError(t, err)
+	redMiddleware, err := RedMetricsMiddleware(swagger, nil)
+	require.NoError(t, err)
+	h := routeMiddleware(redMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			setRedRole(r.Context(), []string{"superuser"})
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	})))
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/users/v1", nil))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/api/users/v1", nil))
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	counts := make(map[string]map[attribute.Distinct]int64)
+	var histogram metricdata.Histogram[float64]
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			switch data := m.Data.(type) {
+			case metricdata.Sum[int64]:
+				counts[m.Name] = make(map[attribute.Distinct]int64)
+				for _, dp := range data.DataPoints {
+					counts[m.Name][dp.Attributes.Equivalent()] = dp.Value
+				}
+			case metricdata.Histogram[float64]:
+				histogram = data
+			}
+		}
+	}
+
+	getUsers := attribute.NewSet(attribute.String("operation", "GetUsers"), attribute.String("status_class", "2xx"), attribute.String("role", "anonymous"))
+	createUser := attribute.NewSet(attribute.String("operation", "CreateUser"), attribute.String("status_class", "5xx"), attribute.String("role", "superuser"))
+	assert.Equal(t, map[attribute.Distinct]int64{getUsers.Equivalent(): 1, createUser.Equivalent(): 1}, counts["http_server_operation_requests"])
+	assert.Equal(t, map[attribute.Distinct]int64{createUser.Equivalent(): 1}, counts["http_server_operation_errors"])
+	require.Len(t, histogram.DataPoints, 2)
+	assert.Equal(t, DefaultDurationBuckets, histogram.DataPoints[0].Bounds)
+}
+
+func TestRedMetrics_Should_Reject_SLO_Latency_Outside_Buckets(t *testing.T) {
+	swagger, err := api.GetSwagger()
+	require.NoError(t, err)
+
+	_, err = OperationSLOs(swagger, []float64{0.1, 1})
+	assert.ErrorContains(t, err, "is not one of duration buckets")
+
+	slos, err := OperationSLOs(swagger, DefaultDurationBuckets)
+	require.NoError(t, err)
+	assert.Contains(t, slos, "GetUser")
+}
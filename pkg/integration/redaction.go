@@ -0,0 +1,267 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/trace"
+)
+
+// Redactor masks sensitive data: values of sensitive keys (attribute names, headers) are replaced completely,
+// parts of any other string value that match one of the patterns are replaced with redactedValue
+type Redactor struct {
+	patterns []*regexp.Regexp
+	keys     map[string]bool
+	prodMode bool
+}
+
+var defaultRedactor atomic.Pointer[Redactor]
+
+// ConfigureRedaction creates redactor from config and makes it default for problem details
+func ConfigureRedaction(cfg RedactionConfig) (*Redactor, error) {
+	r, err := NewRedactor(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defaultRedactor.Store(r)
+	return r, nil
+}
+
+func NewRedactor(cfg RedactionConfig) (*Redactor, error) {
+	r := &Redactor{keys: make(map[string]bool), prodMode: cfg.ProdMode}
+	for _, p := range cfg.Patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compile redaction pattern %s: %w", p, err)
+		}
+		r.patterns = append(r.patterns, re)
+	}
+	for _, k := range cfg.Keys {
+		r.keys[strings.ToLower(k)] = true
+	}
+	return r, nil
+}
+
+func currentRedactor() *Redactor {
+	if r := defaultRedactor.Load(); r != nil {
+		return r
+	}
+	return &Redactor{}
+}
+
+func (r *Redactor) String(s string) string {
+	for _, re := range r.patterns {
+		s = re.ReplaceAllString(s, redactedValue)
+	}
+	return s
+}
+
+// sensitiveKey matches the key with sensitive keys; for JSON pointers (e.g. `#/user/password`) the last segment is matched
+func (r *Redactor) sensitiveKey(key string) bool {
+	if strings.HasPrefix(key, "#/") {
+		key = key[strings.LastIndexByte(key, '/')+1:]
+	}
+	return r.keys[strings.ToLower(key)]
+}
+
+func (r *Redactor) Attr(a slog.Attr) slog.Attr {
+	if r.sensitiveKey(a.Key) {
+		return slog.String(a.Key, redactedValue)
+	}
+	a.Value = a.Value.Resolve()
+	switch a.Value.Kind() {
+	case slog.KindString:
+		a.Value = slog.StringValue(r.String(a.Value.String()))
+	case slog.KindGroup:
+		attrs := a.Value.Group()
+		redacted := make([]slog.Attr, len(attrs))
+		for i, ga := range attrs {
+			redacted[i] = r.Attr(ga)
+		}
+		a.Value = slog.GroupValue(redacted...)
+	case slog.KindAny:
+		switch v := a.Value.Any().(type) {
+		case error:
+			a.Value = slog.StringValue(r.String(v.Error()))
+		case nil:
+		default:
+			a.Value = slog.AnyValue(r.value(v))
+		}
+	}
+	return a
+}
+
+// value masks strings, string slices and maps without changing their representation: values of sensitive keys
+// are replaced and patterns are applied to strings at any depth; other values, e.g. numbers and structs, are kept
+// as is, types carrying sensitive fields should implement slog.LogValuer
+func (r *Redactor) value(v any) any {
+	switch v := v.(type) {
+	case string:
+		return r.String(v)
+	case []string:
+		redacted := make([]string, len(v))
+		for i, item := range v {
+			redacted[i] = r.String(item)
+		}
+		return redacted
+	case []any:
+		redacted := make([]any, len(v))
+		for i, item := range v {
+			redacted[i] = r.value(item)
+		}
+		return redacted
+	case map[string]any:
+		redacted := make(map[string]any, len(v))
+		for k, item := range v {
+			if r.sensitiveKey(k) {
+				redacted[k] = redactedValue
+			} else {
+				redacted[k] = r.value(item)
+			}
+		}
+		return redacted
+	case map[string]string:
+		redacted := make(map[string]string, len(v))
+		for k, item := range v {
+			if r.sensitiveKey(k) {
+				redacted[k] = redactedValue
+			} else {
+				redacted[k] = r.String(item)
+			}
+		}
+		return redacted
+	case http.Header:
+		return http.Header(r.value(map[string][]string(v)).(map[string][]string))
+	case map[string][]string:
+		redacted := make(map[string][]string, len(v))
+		for k, item := range v {
+			if r.sensitiveKey(k) {
+				redacted[k] = []string{redactedValue}
+			} else {
+				redacted[k] = r.value(item).([]string)
+			}
+		}
+		return redacted
+	default:
+		return v
+	}
+}
+
+func (r *Redactor) KeyValue(kv attribute.KeyValue) attribute.KeyValue {
+	if r.sensitiveKey(string(kv.Key)) {
+		return kv.Key.String(redactedValue)
+	}
+	switch kv.Value.Type() {
+	case attribute.STRING:
+		return kv.Key.String(r.String(kv.Value.AsString()))
+	case attribute.STRINGSLICE:
+		values := kv.Value.AsStringSlice()
+		for i := range values {
+			values[i] = r.String(values[i])
+		}
+		return kv.Key.StringSlice(values)
+	}
+	return kv
+}
+
+func (r *Redactor) KeyValues(kvs []attribute.KeyValue) []attribute.KeyValue {
+	redacted := make([]attribute.KeyValue, len(kvs))
+	for i, kv := range kvs {
+		redacted[i] = r.KeyValue(kv)
+	}
+	return redacted
+}
+
+// ProblemDetail returns error that is safe to send to client;
+// in prod mode details of server errors are replaced with generic message, trace id is the way to find the cause
+func (r *Redactor) ProblemDetail(status int, err error) error {
+	if err == nil {
+		return nil
+	}
+	if r.prodMode && status >= 500 {
+		return errors.New("internal error occurred")
+	}
+	return errors.New(r.String(err.Error()))
+}
+
+// RedactingHandler masks sensitive data in log messages and attributes
+type RedactingHandler struct {
+	next     slog.Handler
+	redactor *Redactor
+}
+
+func NewRedactingHandler(next slog.Handler, redactor *Redactor) *RedactingHandler {
+	return &RedactingHandler{next: next, redactor: redactor}
+}
+
+func (h *RedactingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *RedactingHandler) Handle(ctx context.Context, record slog.Record) error {
+	redacted := slog.NewRecord(record.Time, record.Level, h.redactor.String(record.Message), record.PC)
+	record.Attrs(func(a slog.Attr) bool {
+		redacted.AddAttrs(h.redactor.Attr(a))
+		return true
+	})
+	return h.next.Handle(ctx, redacted)
+}
+
+func (h *RedactingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		redacted[i] = h.redactor.Attr(a)
+	}
+	return &RedactingHandler{next: h.next.WithAttrs(redacted), redactor: h.redactor}
+}
+
+func (h *RedactingHandler) WithGroup(name string) slog.Handler {
+	return &RedactingHandler{next: h.next.WithGroup(name), redactor: h.redactor}
+}
+
+// redactingSpanExporter masks sensitive data in span attributes, events and status before export;
+// spans are immutable once ended, so it is done on the way out
+type redactingSpanExporter struct {
+	trace.SpanExporter
+	redactor *Redactor
+}
+
+func (e *redactingSpanExporter) ExportSpans(ctx context.Context, spans []trace.ReadOnlySpan) error {
+	redacted := make([]trace.ReadOnlySpan, len(spans))
+	for i, s := range spans {
+		redacted[i] = redactedSpan{ReadOnlySpan: s, redactor: e.redactor}
+	}
+	return e.SpanExporter.ExportSpans(ctx, redacted)
+}
+
+type redactedSpan struct {
+	trace.ReadOnlySpan
+	redactor *Redactor
+}
+
+func (s redactedSpan) Attributes() []attribute.KeyValue {
+	return s.redactor.KeyValues(s.ReadOnlySpan.Attributes())
+}
+
+func (s redactedSpan) Events() []trace.Event {
+	events := s.ReadOnlySpan.Events()
+	redacted := make([]trace.Event, len(events))
+	for i, e := range events {
+		e.Attributes = s.redactor.KeyValues(e.Attributes)
+		redacted[i] = e
+	}
+	return redacted
+}
+
+func (s redactedSpan) Status() trace.Status {
+	status := s.ReadOnlySpan.Status()
+	status.Description = s.redactor.String(status.Description)
+	return status
+}
@@ -0,0 +1,112 @@
+package integration
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+var testRedactionConfig = RedactionConfig{
+	Patterns: []string{`(?i)bearer\s+[a-z0-9._~+/=-]+`, `[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}`},
+	Keys:     []string{"Authorization", "password"},
+}
+
+func TestRedaction_Should_Mask_Log_Attributes(t *testing.T) {
+	redactor, err := NewRedactor(testRedactionConfig)
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	log := slog.New(NewRedactingHandler(slog.NewTextHandler(&buf, nil), redactor))
+
+	log.With("password", "hunter2").Info("user john@example.com logged in",
+		"err", errors.New("token Bearer abc.def is expired"),
+		slog.Group("headers", slog.String("authorization", "Basic dXNlcg==")),
+	)
+
+	out := buf.String()
+	assert.NotContains(t, out, "hunter2")
+	assert.NotContains(t, out, "john@example.com")
+	assert.NotContains(t, out, "abc.def")
+	assert.NotContains(t, out, "dXNlcg==")
+	assert.Contains(t, out, `msg="user *** logged in"`)
+	assert.Contains(t, out, `err="token *** is expired"`)
+}
+
+type testCredentials struct {
+	User     string
+	Password string
+}
+
+func (c testCredentials) LogValue() slog.Value {
+	return slog.GroupValue(slog.String("user", c.User), slog.String("password", c.Password))
+}
+
+func TestRedaction_Should_Mask_Structured_Log_Values(t *testing.T) {
+	redactor, err := NewRedactor(testRedactionConfig)
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	log := slog.New(NewRedactingHandler(slog.NewJSONHandler(&buf, nil), redactor))
+
+	log.Info("login",
+		"credentials", testCredentials{User: "john", Password: "hunter2"},
+		"password", []string{"hunter2"},
+		"emails", []string{"john@example.com"},
+		"headers", map[string]any{"Authorization": []string{"Basic dXNlcg=="}},
+		"request", http.Header{"Authorization": {"Basic dXNlcg=="}, "From": {"john@example.com"}},
+	)
+
+	out := buf.String()
+	assert.NotContains(t, out, "hunter2")
+	assert.NotContains(t, out, "john@example.com")
+	assert.NotContains(t, out, "dXNlcg==")
+	assert.Contains(t, out, `"credentials":{"user":"john","password":"***"}`)
+	assert.True(t, redactor.sensitiveKey("#/user/password"))
+}
+
+func TestRedaction_Should_Keep_Representation_Of_Non_String_Values(t *testing.T) {
+	redactor, err := NewRedactor(testRedactionConfig)
+	require.NoError(t, err)
+	point := struct{ X, Y int }{X: 1, Y: 2}
+	values := map[string]any{"ids": []int{1, 2}, "email": "john@example.com"}
+
+	assert.Equal(t, []int{1, 2}, redactor.Attr(slog.Any("ids", []int{1, 2})).Value.Any())
+	assert.Equal(t, point, redactor.Attr(slog.Any("point", point)).Value.Any())
+	assert.Equal(t, map[string]any{"ids": []int{1, 2}, "email": "***"}, redactor.Attr(slog.Any("values", values)).Value.Any())
+	assert.Equal(t, "john@example.com", values["email"], "logged value must not be modified")
+}
+
+func TestRedaction_Should_Mask_Exported_Spans(t *testing.T) {
+	redactor, err := NewRedactor(testRedactionConfig)
+	require.NoError(t, err)
+	exporter := tracetest.NewInMemoryExporter()
+	tp := trace.NewTracerProvider(trace.WithSyncer(&redactingSpanExporter{SpanExporter: exporter, redactor: redactor}))
+
+	_, span := tp.Tracer("test").Start(context.Background(), "span")
+	span.SetAttributes(attribute.String("user.email", "john@example.com"), attribute.String("password", "hunter2"))
+	span.RecordError(errors.New("john@example.com is not found"))
+	span.End()
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	assert.Contains(t, spans[0].Attributes, attribute.String("user.email", "***"))
+	assert.Contains(t, spans[0].Attributes, attribute.String("password", "***"))
+	assert.Contains(t, spans[0].Events[0].Attributes, attribute.String("exception.message", "*** is not found"))
+}
+
+func TestRedaction_Should_Hide_Server_Error_Details_In_Prod_Mode(t *testing.T) {
+	cfg := testRedactionConfig
+	cfg.ProdMode = true
+	redactor, err := NewRedactor(cfg)
+	require.NoError(t, err)
+
+	assert.EqualError(t, redactor.ProblemDetail(http.StatusInternalServerError, errors.New("db at 10.0.0.1 is down")), "internal error occurred")
+	assert.EqualError(t, redactor.ProblemDetail(http.StatusBadRequest, errors.New("john@example.com is taken")), "*** is taken")
+}
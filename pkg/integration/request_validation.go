@@ -0,0 +1,126 @@
+package integration
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"golang-http-service/api"
+)
+
+// RequestValidationError is returned when request does not match the spec, it keeps all violations
+type RequestValidationError struct {
+	err    error
+	params []api.InvalidParam
+}
+
+func newRequestValidationError(err error) *RequestValidationError {
+	return &RequestValidationError{err: err, params: invalidParamsOf(err)}
+}
+
+func (e *RequestValidationError) Error() string {
+	reasons := make([]string, len(e.params))
+	for i, p := range e.params {
+		switch {
+		case p.Pointer != "":
+			reasons[i] = p.Pointer + ": " + p.Reason
+		case p.Name != "":
+			reasons[i] = p.Name + ": " + p.Reason
+		default:
+			reasons[i] = p.Reason
+		}
+	}
+	return "request does not match the spec: " + strings.Join(reasons, "; ")
+}
+
+func (e *RequestValidationError) Unwrap() error {
+	return e.err
+}
+
+// InvalidParams returns violations with raw rejected values, they must be redacted before leaving the service
+func (e *RequestValidationError) InvalidParams() []api.InvalidParam {
+	return e.params
+}
+
+func IsRequestValidationError(err error) bool {
+	var requestValidationError *RequestValidationError
+	return errors.As(err, &requestValidationError)
+}
+
+// invalidParamsOf flattens kin-openapi errors; request body fields are located by JSON pointer, parameters by their name
+func invalidParamsOf(err error) []api.InvalidParam {
+	switch e := err.(type) {
+	case openapi3.MultiError:
+		var params []api.InvalidParam
+		for _, err := range e {
+			params = append(params, invalidParamsOf(err)...)
+		}
+		return params
+	case *openapi3filter.RequestError:
+		switch {
+		case e.Parameter != nil:
+			return requestErrorParams(api.InvalidParam{In: e.Parameter.In, Name: e.Parameter.Name}, e)
+		case e.RequestBody != nil:
+			return requestErrorParams(api.InvalidParam{Pointer: "#"}, e)
+		}
+	}
+	return []api.InvalidParam{{Reason: err.Error()}}
+}
+
+func requestErrorParams(location api.InvalidParam, e *openapi3filter.RequestError) []api.InvalidParam {
+	var params []api.InvalidParam
+	var visit func(err error)
+	visit = func(err error) {
+		p := location
+		switch se := err.(type) {
+		case openapi3.MultiError:
+			for _, err := range se {
+				visit(err)
+			}
+			return
+		case *openapi3.SchemaError:
+			p.Reason = se.Reason
+			if p.Pointer != "" {
+				p.Pointer = jsonPointer(se.JSONPointer())
+			}
+			// value of missing property is the enclosing object, it does not help to find the cause
+			if se.SchemaField != "required" {
+				p.Value = se.Value
+			}
+		case *openapi3filter.ParseError:
+			p.Reason = se.Error()
+			p.Value = se.Value
+		default:
+			p.Reason = e.Reason
+			if err != nil {
+				p.Reason = strings.TrimPrefix(p.Reason+": "+err.Error(), ": ")
+			}
+		}
+		params = append(params, p)
+	}
+	visit(e.Err)
+	return params
+}
+
+// problemInvalidParams converts violations of request validation error to `invalidParams` extension member,
+// rejected values are redacted
+func problemInvalidParams(err error, redactor *Redactor) *[]api.InvalidParam {
+	var requestValidationError *RequestValidationError
+	if !errors.As(err, &requestValidationError) || len(requestValidationError.params) == 0 {
+		return nil
+	}
+	params := make([]api.InvalidParam, len(requestValidationError.params))
+	for i, p := range requestValidationError.params {
+		p.Reason = redactor.String(p.Reason)
+		switch {
+		case p.Value == nil:
+		case redactor.sensitiveKey(p.Pointer) || redactor.sensitiveKey(p.Name):
+			p.Value = redactedValue
+		default:
+			p.Value = redactor.value(p.Value)
+		}
+		params[i] = p
+	}
+	return &params
+}
@@ -0,0 +1,98 @@
+package integration
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang-http-service/api"
+)
+
+func validatingHandler(t *testing.T) http.Handler {
+	swagger, err := api.GetSwagger()
+	require.NoError(t, err)
+	routeMiddleware, err := RouteMiddleware(swagger)
+	require.NoError(t, err)
+	return routeMiddleware(OpenapiValidationMiddleware(swagger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})))
+}
+
+func serveProblem(t *testing.T, h http.Handler, r *http.Request) api.ProblemDetail {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, r)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	var p api.ProblemDetail
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &p))
+	return p
+}
+
+func TestOpenapiValidation_Should_Report_All_Body_Violations(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/api/users/v1", strings.NewReader(`{"id": -1, "name": ""}`))
+	r.Header.Set("Content-Type", "application/json")
+
+	p := serveProblem(t, validatingHandler(t), r)
+
+	assert.Equal(t, InvalidRequestProblem.Type, p.Type)
+	require.NotNil(t, p.InvalidParams)
+	params := make(map[string]api.InvalidParam)
+	for _, param := range *p.InvalidParams {
+		params[param.Pointer] = param
+	}
+	require.Len(t, params, 2)
+	assert.Empty(t, params["#/id"].Name)
+	assert.EqualValues(t, -1, params["#/id"].Value)
+	assert.Equal(t, "", params["#/name"].Value)
+	assert.NotEmpty(t, params["#/name"].Reason)
+}
+
+func TestOpenapiValidation_Should_Omit_Value_Of_Missing_Field(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/api/users/v1", strings.NewReader(`{"id": 1}`))
+	r.Header.Set("Content-Type", "application/json")
+
+	p := serveProblem(t, validatingHandler(t), r)
+
+	require.NotNil(t, p.InvalidParams)
+	require.Len(t, *p.InvalidParams, 1)
+	assert.Equal(t, "#/name", (*p.InvalidParams)[0].Pointer)
+	assert.Nil(t, (*p.InvalidParams)[0].Value)
+}
+
+func TestOpenapiValidation_Should_Report_Path_Parameter(t *testing.T) {
+	p := serveProblem(t, validatingHandler(t), httptest.NewRequest(http.MethodGet, "/api/users/v1/-5", nil))
+
+	require.NotNil(t, p.InvalidParams)
+	require.Len(t, *p.InvalidParams, 1)
+	assert.Equal(t, api.InvalidParam{In: "path", Name: "userid", Reason: (*p.InvalidParams)[0].Reason, Value: float64(-5)}, (*p.InvalidParams)[0])
+}
+
+func TestOpenapiValidation_Should_Redact_Rejected_Values(t *testing.T) {
+	_, err := ConfigureRedaction(RedactionConfig{Keys: []string{"name"}, Patterns: []string{`\d{4}-\d{4}`}})
+	require.NoError(t, err)
+	t.Cleanup(func() { defaultRedactor.Store(nil) })
+	r := httptest.NewRequest(http.MethodPost, "/api/users/v1", strings.NewReader(`{"id": "1234-5678", "name": 5}`))
+	r.Header.Set("Content-Type", "application/json")
+
+	p := serveProblem(t, validatingHandler(t), r)
+
+	require.NotNil(t, p.InvalidParams)
+	values := make(map[string]any)
+	for _, param := range *p.InvalidParams {
+		values[param.Pointer] = param.Value
+	}
+	assert.Equal(t, map[string]any{"#/id": redactedValue, "#/name": redactedValue}, values)
+}
+
+func TestOpenapiValidation_Should_Pass_Valid_Request(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/api/users/v1", strings.NewReader(`{"id": 1, "name": "bob"}`))
+	r.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+
+	validatingHandler(t).ServeHTTP(rec, r)
+
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+}
@@ -0,0 +1,107 @@
+package integration
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"golang-http-service/pkg/integration/logctx"
+)
+
+var responseViolationsCounter = sync.OnceValue(func() metric.Int64Counter {
+	counter, err := otel.Meter("golang-http-service").Int64Counter("http_server_response_violations",
+		metric.WithDescription("Responses that do not match the openapi spec by operation"),
+	)
+	if err != nil {
+		otel.Handle(fmt.Errorf("failed to create response violations counter: %w", err))
+	}
+	return counter
+})
+
+// ResponseValidationMiddleware buffers responses of the api operations and validates them against the spec:
+// undeclared statuses, headers and bodies that do not match the schema are logged, or replaced with 500 problem
+// when failOnViolation is set; server errors are not validated, they are reported on their own
+func ResponseValidationMiddleware(failOnViolation bool) func(next http.Handler) http.Handler {
+	options := &openapi3filter.Options{
+		IncludeResponseStatus: true,
+		MultiError:            true,
+		AuthenticationFunc:    openapi3filter.NoopAuthenticationFunc,
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route, ok := r.Context().Value(RouteKey{}).(*routers.Route)
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+			bw := &bufferedResponseWriter{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(bw, r)
+			if bw.status < http.StatusInternalServerError {
+				pathParams, _ := r.Context().Value(routePathParamsKey{}).(map[string]string)
+				input := &openapi3filter.ResponseValidationInput{
+					RequestValidationInput: &openapi3filter.RequestValidationInput{
+						Request:    r,
+						PathParams: pathParams,
+						Route:      route,
+						Options:    options,
+					},
+					Status:  bw.status,
+					Header:  w.Header(),
+					Body:    io.NopCloser(bytes.NewReader(bw.body.Bytes())),
+					Options: options,
+				}
+				if err := openapi3filter.ValidateResponse(r.Context(), input); err != nil {
+					recordResponseViolation(r.Context(), route.Operation.OperationID, bw.status, err)
+					if failOnViolation {
+						clear(w.Header())
+						HandleHTTPServerError(w, r, fmt.Errorf("response does not match the spec: %w", err))
+						return
+					}
+				}
+			}
+			bw.flush(r.Context())
+		})
+	}
+}
+
+func recordResponseViolation(ctx context.Context, operation string, status int, err error) {
+	logctx.From(ctx).WarnContext(ctx, "response does not match the spec", "operation", operation, "status", status, "err", err)
+	if counter := responseViolationsCounter(); counter != nil {
+		counter.Add(context.WithoutCancel(ctx), 1, metric.WithAttributes(attribute.String("operation", operation)))
+	}
+}
+
+// bufferedResponseWriter holds status and body until the response is validated, headers go to the original writer
+type bufferedResponseWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (w *bufferedResponseWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+}
+
+func (w *bufferedResponseWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	return w.body.Write(b)
+}
+
+func (w *bufferedResponseWriter) flush(ctx context.Context) {
+	w.ResponseWriter.WriteHeader(w.status)
+	if _, err := w.ResponseWriter.Write(w.body.Bytes()); err != nil {
+		logctx.From(ctx).ErrorContext(ctx, "failed to write validated response", "err", err)
+	}
+}
@@ -0,0 +1,62 @@
+package integration
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang-http-service/api"
+)
+
+func responseValidatingHandler(t *testing.T, failOnViolation bool, status int, body string) http.Handler {
+	swagger, err := api.GetSwagger()
+	require.NoError(t, err)
+	routeMiddleware, err := RouteMiddleware(swagger)
+	require.NoError(t, err)
+	return routeMiddleware(ResponseValidationMiddleware(failOnViolation)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	})))
+}
+
+func TestResponseValidation_Should_Pass_Valid_Response(t *testing.T) {
+	rec := httptest.NewRecorder()
+
+	responseValidatingHandler(t, true, http.StatusOK, `[{"id": 1, "name": "bob"}]`).
+		ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/users/v1", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `[{"id": 1, "name": "bob"}]`, rec.Body.String())
+}
+
+func TestResponseValidation_Should_Fail_On_Invalid_Body(t *testing.T) {
+	rec := httptest.NewRecorder()
+
+	responseValidatingHandler(t, true, http.StatusOK, `[{"id": 1, "name": ""}]`).
+		ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/users/v1", nil))
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Equal(t, "application/problem+json", rec.Header().Get("Content-Type"))
+}
+
+func TestResponseValidation_Should_Fail_On_Undeclared_Status(t *testing.T) {
+	rec := httptest.NewRecorder()
+
+	responseValidatingHandler(t, true, http.StatusTeapot, `{}`).
+		ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/users/v1", nil))
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+}
+
+func TestResponseValidation_Should_Only_Log_Violation_By_Default(t *testing.T) {
+	rec := httptest.NewRecorder()
+
+	responseValidatingHandler(t, false, http.StatusOK, `[{"id": 1, "name": ""}]`).
+		ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/users/v1", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `[{"id": 1, "name": ""}]`, rec.Body.String())
+}
@@ -0,0 +1,142 @@
+package integration
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/sdk/trace"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// CreateTraceSampler builds head sampler from config:
+// operation rules are checked first, then the strategy sampler; with ParentBased the decision is inherited from the parent span
+func CreateTraceSampler(cfg TraceSamplingConfig) (trace.Sampler, error) {
+	var base trace.Sampler
+	switch cfg.Strategy {
+	case "", "always":
+		base = trace.AlwaysSample()
+	case "never":
+		base = trace.NeverSample()
+	case "ratio":
+		base = trace.TraceIDRatioBased(cfg.Ratio)
+	case "ratelimited":
+		base = newRateLimitedSampler(cfg.Rate)
+	default:
+		return nil, fmt.Errorf("unknown sampling strategy %s", cfg.Strategy)
+	}
+
+	recordDropped := cfg.TailCaptureEnabled()
+	root := &ruleSampler{
+		base:          base,
+		operations:    cfg.AlwaysSampleOperations,
+		recordDropped: recordDropped,
+	}
+	if !cfg.ParentBased {
+		return root, nil
+	}
+
+	// not sampled children are still recorded when tail capture is on, so TailCaptureProcessor can export them
+	notSampled := trace.NeverSample()
+	if recordDropped {
+		notSampled = recordOnlySampler{}
+	}
+	return trace.ParentBased(root,
+		trace.WithRemoteParentNotSampled(notSampled),
+		trace.WithLocalParentNotSampled(notSampled),
+	), nil
+}
+
+type ruleSampler struct {
+	base          trace.Sampler
+	operations    []string
+	recordDropped bool
+}
+
+func (s *ruleSampler) ShouldSample(p trace.SamplingParameters) trace.SamplingResult {
+	if operationID := OperationIDFromContext(p.ParentContext); operationID != "" {
+		for _, op := range s.operations {
+			// case-insensitive to match both openapi operation ids and labels set by labelRequest
+			if strings.EqualFold(op, operationID) {
+				return trace.SamplingResult{
+					Decision:   trace.RecordAndSample,
+					Tracestate: oteltrace.SpanContextFromContext(p.ParentContext).TraceState(),
+				}
+			}
+		}
+	}
+	res := s.base.ShouldSample(p)
+	if res.Decision == trace.Drop && s.recordDropped {
+		res.Decision = trace.RecordOnly
+	}
+	return res
+}
+
+func (s *ruleSampler) Description() string {
+	return fmt.Sprintf("RuleSampler{operations:%v,recordDropped:%t,base:%s}", s.operations, s.recordDropped, s.base.Description())
+}
+
+type recordOnlySampler struct{}
+
+func (s recordOnlySampler) ShouldSample(p trace.SamplingParameters) trace.SamplingResult {
+	return trace.SamplingResult{
+		Decision:   trace.RecordOnly,
+		Tracestate: oteltrace.SpanContextFromContext(p.ParentContext).TraceState(),
+	}
+}
+
+func (s recordOnlySampler) Description() string {
+	return "RecordOnlySampler"
+}
+
+// rateLimitedSampler samples at most rate traces per second using token bucket
+type rateLimitedSampler struct {
+	mu       sync.Mutex
+	rate     float64
+	tokens   float64
+	lastTick time.Time
+}
+
+func newRateLimitedSampler(rate float64) *rateLimitedSampler {
+	return &rateLimitedSampler{rate: rate, tokens: rate, lastTick: time.Now()}
+}
+
+func (s *rateLimitedSampler) ShouldSample(p trace.SamplingParameters) trace.SamplingResult {
+	res := trace.SamplingResult{
+		Decision:   trace.Drop,
+		Tracestate: oteltrace.SpanContextFromContext(p.ParentContext).TraceState(),
+	}
+	if s.take() {
+		res.Decision = trace.RecordAndSample
+	}
+	return res
+}
+
+func (s *rateLimitedSampler) take() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	// burst is at least one trace, so rates below 1/s still sample
+	s.tokens = min(max(s.rate, 1), s.tokens+now.Sub(s.lastTick).Seconds()*s.rate)
+	s.lastTick = now
+	if s.tokens < 1 {
+		return false
+	}
+	s.tokens--
+	return true
+}
+
+func (s *rateLimitedSampler) Description() string {
+	return fmt.Sprintf("RateLimitedSampler{%g}", s.rate)
+}
+
+// forceSampledSpan makes batch processor export the span that was only recorded by the sampler
+type forceSampledSpan struct {
+	trace.ReadOnlySpan
+}
+
+func (s forceSampledSpan) SpanContext() oteltrace.SpanContext {
+	sc := s.ReadOnlySpan.SpanContext()
+	return sc.WithTraceFlags(sc.TraceFlags().WithSampled(true))
+}
@@ -0,0 +1,147 @@
+package integration
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	oteltrace "go.opentelemetry.io/otel/trace"
+	"golang-http-service/api"
+)
+
+func TestSampling_Should_Keep_Configured_Operations_And_Errors(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := newTestTracerProvider(t, exporter, TraceSamplingConfig{
+		Strategy:               "never",
+		ParentBased:            true,
+		AlwaysSampleErrors:     true,
+		AlwaysSampleOperations: []string{"createUser"},
+	})
+	tracer := tp.Tracer("test")
+
+	_, span := tracer.Start(operationContext(t, http.MethodPost, "/api/users/v1"), "createUser")
+	span.End()
+	_, span = tracer.Start(operationContext(t, http.MethodGet, "/api/users/v1"), "getUsers")
+	span.End()
+	ctx, span := tracer.Start(operationContext(t, http.MethodGet, "/api/users/v1/1"), "getUser")
+	_, child := tracer.Start(ctx, "child")
+	child.SetStatus(codes.Error, "boom")
+	child.End()
+	span.End()
+
+	require.NoError(t, tp.ForceFlush(context.Background()))
+	var names []string
+	for _, s := range exporter.GetSpans() {
+		names = append(names, s.Name)
+		assert.True(t, s.SpanContext.IsSampled())
+	}
+	assert.ElementsMatch(t, []string{"createUser", "getUser", "child"}, names)
+}
+
+func TestSampling_Should_Keep_Slow_Traces(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := newTestTracerProvider(t, exporter, TraceSamplingConfig{
+		Strategy:         "never",
+		ParentBased:      true,
+		LatencyThreshold: time.Second,
+	})
+	tracer := tp.Tracer("test")
+
+	start := time.Now()
+	ctx, span := tracer.Start(context.Background(), "slow", oteltrace.WithTimestamp(start))
+	_, child := tracer.Start(ctx, "child", oteltrace.WithTimestamp(start))
+	child.End(oteltrace.WithTimestamp(start.Add(10 * time.Millisecond)))
+	span.End(oteltrace.WithTimestamp(start.Add(2 * time.Second)))
+	ctx, span = tracer.Start(context.Background(), "fast")
+	_, child = tracer.Start(ctx, "child")
+	child.End()
+	span.End()
+
+	require.NoError(t, tp.ForceFlush(context.Background()))
+	var names []string
+	for _, s := range exporter.GetSpans() {
+		names = append(names, s.Name)
+	}
+	assert.ElementsMatch(t, []string{"slow", "child"}, names)
+}
+
+func TestSampling_Should_Bound_Buffered_Traces(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := newTestTracerProvider(t, exporter, TraceSamplingConfig{
+		Strategy:           "never",
+		ParentBased:        true,
+		AlwaysSampleErrors: true,
+		MaxBufferedTraces:  1,
+		MaxSpansPerTrace:   1,
+	})
+	tracer := tp.Tracer("test")
+
+	ctx1, first := tracer.Start(context.Background(), "first")
+	_, child := tracer.Start(ctx1, "first-child")
+	child.End()
+	ctx2, second := tracer.Start(context.Background(), "second")
+	_, child = tracer.Start(ctx2, "second-child")
+	child.SetStatus(codes.Error, "boom")
+	child.End()
+	_, child = tracer.Start(ctx2, "second-extra")
+	child.End()
+	first.SetStatus(codes.Error, "boom")
+	first.End()
+	second.End()
+
+	require.NoError(t, tp.ForceFlush(context.Background()))
+	var names []string
+	for _, s := range exporter.GetSpans() {
+		names = append(names, s.Name)
+	}
+	// first trace was evicted before its error, second is capped at one span
+	assert.ElementsMatch(t, []string{"first", "second-child"}, names)
+}
+
+func TestSampling_Should_Limit_Rate(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := newTestTracerProvider(t, exporter, TraceSamplingConfig{Strategy: "ratelimited", Rate: 2})
+	tracer := tp.Tracer("test")
+
+	for i := 0; i < 10; i++ {
+		_, span := tracer.Start(context.Background(), "span")
+		span.End()
+	}
+
+	require.NoError(t, tp.ForceFlush(context.Background()))
+	assert.Len(t, exporter.GetSpans(), 2)
+}
+
+func newTestTracerProvider(t *testing.T, exporter trace.SpanExporter, cfg TraceSamplingConfig) *trace.TracerProvider {
+	sampler, err := CreateTraceSampler(cfg)
+	require.NoError(t, err)
+	var processor trace.SpanProcessor = trace.NewSimpleSpanProcessor(exporter)
+	if cfg.TailCaptureEnabled() {
+		processor, err = NewTailCaptureProcessor(processor, cfg)
+		require.NoError(t, err)
+	}
+	tp := trace.NewTracerProvider(trace.WithSampler(sampler), trace.WithSpanProcessor(processor))
+	t.Cleanup(func() { _ = tp.Shutdown(context.Background()) })
+	return tp
+}
+
+// operationContext returns request context as it is seen by handlers behind RouteMiddleware
+func operationContext(t *testing.T, method string, target string) context.Context {
+	swagger, err := api.GetSwagger()
+	require.NoError(t, err)
+	mdl, err := RouteMiddleware(swagger)
+	require.NoError(t, err)
+	var ctx context.Context
+	mdl(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		ctx = r.Context()
+	})).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(method, target, nil))
+	require.NotEmpty(t, OperationIDFromContext(ctx))
+	return ctx
+}
@@ -0,0 +1,184 @@
+package integration
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/sdk/trace"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// TailCaptureProcessor buffers spans of not sampled traces until the local root span ends;
+// the whole trace is exported when any of its spans failed or was slower than the threshold.
+// Sampled spans are passed to the next processor as is.
+type TailCaptureProcessor struct {
+	next             trace.SpanProcessor
+	keepErrors       bool
+	latencyThreshold time.Duration
+	maxTraces        int
+	maxSpansPerTrace int
+
+	mu     sync.Mutex
+	traces map[oteltrace.TraceID]*capturedTrace
+	order  *list.List // trace ids, oldest first
+
+	decisions metric.Int64Counter
+}
+
+const (
+	defaultMaxBufferedTraces = 1000
+	defaultMaxSpansPerTrace  = 256
+)
+
+type capturedTrace struct {
+	spans []trace.ReadOnlySpan
+	keep  bool
+	elem  *list.Element
+}
+
+func NewTailCaptureProcessor(next trace.SpanProcessor, cfg TraceSamplingConfig) (*TailCaptureProcessor, error) {
+	p := &TailCaptureProcessor{
+		next:             next,
+		keepErrors:       cfg.AlwaysSampleErrors,
+		latencyThreshold: cfg.LatencyThreshold,
+		maxTraces:        cfg.MaxBufferedTraces,
+		maxSpansPerTrace: cfg.MaxSpansPerTrace,
+		traces:           make(map[oteltrace.TraceID]*capturedTrace),
+		order:            list.New(),
+	}
+	if p.maxTraces <= 0 {
+		p.maxTraces = defaultMaxBufferedTraces
+	}
+	if p.maxSpansPerTrace <= 0 {
+		p.maxSpansPerTrace = defaultMaxSpansPerTrace
+	}
+
+	meter := otel.Meter("golang-http-service")
+	var err error
+	p.decisions, err = meter.Int64Counter("tail_capture_traces",
+		metric.WithDescription("Not sampled traces processed by tail capture, by decision: kept, dropped or evicted"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create tail capture counter: %w", err)
+	}
+	_, err = meter.Int64ObservableGauge("tail_capture_buffered_traces",
+		metric.WithDescription("Not sampled traces currently buffered by tail capture"),
+		metric.WithInt64Callback(func(_ context.Context, o metric.Int64Observer) error {
+			p.mu.Lock()
+			defer p.mu.Unlock()
+			o.Observe(int64(len(p.traces)))
+			return nil
+		}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create tail capture gauge: %w", err)
+	}
+	return p, nil
+}
+
+func (p *TailCaptureProcessor) OnStart(parent context.Context, s trace.ReadWriteSpan) {
+	p.next.OnStart(parent, s)
+}
+
+func (p *TailCaptureProcessor) OnEnd(s trace.ReadOnlySpan) {
+	if s.SpanContext().IsSampled() {
+		p.next.OnEnd(s)
+		return
+	}
+
+	interesting := (p.keepErrors && s.Status().Code == codes.Error) ||
+		(p.latencyThreshold > 0 && s.EndTime().Sub(s.StartTime()) > p.latencyThreshold)
+	localRoot := !s.Parent().IsValid() || s.Parent().IsRemote()
+	traceID := s.SpanContext().TraceID()
+
+	p.mu.Lock()
+	ct, ok := p.traces[traceID]
+	if !ok {
+		if localRoot && !interesting {
+			// single span trace, nothing to wait for
+			p.mu.Unlock()
+			p.record("dropped")
+			return
+		}
+		ct = p.add(traceID)
+	}
+	// spans above the limit are dropped, the rest of the trace is still exported
+	if len(ct.spans) < p.maxSpansPerTrace {
+		ct.spans = append(ct.spans, s)
+	}
+	ct.keep = ct.keep || interesting
+	var evicted []*capturedTrace
+	if localRoot {
+		p.remove(traceID, ct)
+	} else {
+		for len(p.traces) > p.maxTraces {
+			oldestID := p.order.Front().Value.(oteltrace.TraceID)
+			evicted = append(evicted, p.traces[oldestID])
+			p.remove(oldestID, p.traces[oldestID])
+		}
+	}
+	p.mu.Unlock()
+
+	if localRoot {
+		p.decide(ct, false)
+	}
+	for _, e := range evicted {
+		p.decide(e, true)
+	}
+}
+
+func (p *TailCaptureProcessor) add(traceID oteltrace.TraceID) *capturedTrace {
+	ct := &capturedTrace{elem: p.order.PushBack(traceID)}
+	p.traces[traceID] = ct
+	return ct
+}
+
+func (p *TailCaptureProcessor) remove(traceID oteltrace.TraceID, ct *capturedTrace) {
+	p.order.Remove(ct.elem)
+	delete(p.traces, traceID)
+}
+
+// decide exports kept trace; evicted traces that are already known to be interesting are exported partially
+func (p *TailCaptureProcessor) decide(ct *capturedTrace, evicted bool) {
+	if !ct.keep {
+		if evicted {
+			p.record("evicted")
+		} else {
+			p.record("dropped")
+		}
+		return
+	}
+	for _, s := range ct.spans {
+		p.next.OnEnd(forceSampledSpan{ReadOnlySpan: s})
+	}
+	p.record("kept")
+}
+
+func (p *TailCaptureProcessor) record(decision string) {
+	p.decisions.Add(context.Background(), 1, metric.WithAttributes(attribute.String("decision", decision)))
+}
+
+func (p *TailCaptureProcessor) Shutdown(ctx context.Context) error {
+	p.mu.Lock()
+	pending := make([]*capturedTrace, 0, len(p.traces))
+	for id, ct := range p.traces {
+		pending = append(pending, ct)
+		p.remove(id, ct)
+	}
+	p.mu.Unlock()
+	for _, ct := range pending {
+		p.decide(ct, true)
+	}
+	return p.next.Shutdown(ctx)
+}
+
+func (p *TailCaptureProcessor) ForceFlush(ctx context.Context) error {
+	return p.next.ForceFlush(ctx)
+}
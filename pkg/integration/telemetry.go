@@ -4,89 +4,133 @@ import (
 	"bytes"
 	"context"
 	"fmt"
-	"io"
 	"log"
 	"log/slog"
 	"net/http"
 	"os"
-	"strconv"
 	"time"
 
 	"github.com/alexliesenfeld/health"
 	jwtmiddleware "github.com/auth0/go-jwt-middleware/v2"
 	"github.com/auth0/go-jwt-middleware/v2/validator"
-	"github.com/felixge/httpsnoop"
 	"github.com/go-logr/logr"
 	"github.com/lmittmann/tint"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	slogotel "github.com/remychantenay/slog-otel"
+	"go.opentelemetry.io/contrib/bridges/otelslog"
 	"go.opentelemetry.io/contrib/instrumentation/host"
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 	"go.opentelemetry.io/contrib/instrumentation/runtime"
 	"go.opentelemetry.io/contrib/propagators/autoprop"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
-	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
 	promexporter "go.opentelemetry.io/otel/exporters/prometheus"
 	"go.opentelemetry.io/otel/exporters/stdout/stdoutmetric"
 	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/log/global"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
 	"go.opentelemetry.io/otel/sdk/metric"
 	"go.opentelemetry.io/otel/sdk/resource"
 	"go.opentelemetry.io/otel/sdk/trace"
 	oteltrace "go.opentelemetry.io/otel/trace"
+	"golang-http-service/pkg/integration/logctx"
 
-	semconv "go.opentelemetry.io/otel/semconv/v1.18.0"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
 )
 
-func CreateTelemetryResource(ctx context.Context) (*resource.Resource, error) {
-	res, err := resource.New(ctx,
-		resource.WithContainer(),
-		//resource.WithHost(),
-		//resource.WithProcess(),
-		//resource.WithTelemetrySDK(),
-		resource.WithSchemaURL("https://opentelemetry.io/schemas/1.7.0"),
-		resource.WithContainerID(),
-		//resource.WithOS(),
-		resource.WithFromEnv(),
-	)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create resource: %w", err)
+func CreateTelemetryResource(ctx context.Context, serviceName string, serviceNamespace string, serviceVersion string, environment string, detectors []string) (*resource.Resource, error) {
+	buildInfo := ReadBuildInfo()
+	if serviceVersion == "" {
+		serviceVersion = buildInfo.Version
+	}
+	attrs := []attribute.KeyValue{
+		semconv.ServiceName(serviceName),
+		semconv.ServiceVersion(serviceVersion),
+		semconv.ProcessRuntimeVersion(buildInfo.GoVersion),
+		attribute.String("vcs.revision", buildInfo.Revision),
+		attribute.Bool("vcs.modified", buildInfo.Modified),
 	}
-	merged, err := resource.Merge(res, resource.NewSchemaless(semconv.ServiceName("app")))
+	if serviceNamespace != "" {
+		attrs = append(attrs, semconv.ServiceNamespace(serviceNamespace))
+	}
+	if environment != "" {
+		attrs = append(attrs, semconv.DeploymentEnvironment(environment))
+	}
+
+	// detectors are applied in order after static attributes, so the later ones win on conflicts;
+	// e.g. `env` detector allows to override any attribute with OTEL_RESOURCE_ATTRIBUTES
+	opts := []resource.Option{
+		resource.WithSchemaURL(semconv.SchemaURL),
+		resource.WithAttributes(attrs...),
+	}
+	for _, detector := range detectors {
+		switch detector {
+		case "container":
+			opts = append(opts, resource.WithContainer())
+		case "host":
+			opts = append(opts, resource.WithHost())
+		case "process":
+			opts = append(opts, resource.WithProcess())
+		case "os":
+			opts = append(opts, resource.WithOS())
+		case "sdk":
+			opts = append(opts, resource.WithTelemetrySDK())
+		case "env":
+			opts = append(opts, resource.WithFromEnv())
+		default:
+			return nil, fmt.Errorf("unknown resource detector %s", detector)
+		}
+	}
+
+	res, err := resource.New(ctx, opts...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to merge static resources: %w", err)
+		return nil, fmt.Errorf("failed to create resource: %w", err)
 	}
 
 	otel.SetTextMapPropagator(autoprop.NewTextMapPropagator())
 	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
-		slog.Error("otel error", "err", err)
+		NamedLogger("otel").Error("otel error", "err", err)
 	}))
-	return merged, nil
+	return res, nil
 }
 
-func ConfigureTraceProvider(ctx context.Context, res *resource.Resource, output string) (*trace.TracerProvider, error) {
+func ConfigureTraceProvider(ctx context.Context, res *resource.Resource, output string, otlpCfg OtlpExporterConfig, samplingCfg TraceSamplingConfig, redactor *Redactor) (*trace.TracerProvider, error) {
 	var exporter trace.SpanExporter
-	if output == "remote" {
-		var err error
-		if exporter, err = otlptracegrpc.New(ctx); err != nil {
-			return nil, fmt.Errorf("failed to create trace exporter: %w", err)
-		}
-	} else {
-		var writer io.Writer
-		if output == "noop" {
-			writer = NoopWriter{}
-		} else {
-			writer = &SlogWriter{Log: slog.Default(), Level: slog.LevelDebug}
-		}
-		var err error
-		if exporter, err = stdouttrace.New(stdouttrace.WithWriter(writer)); err != nil {
-			return nil, fmt.Errorf("failed to create trace exporter: %w", err)
+	var err error
+	switch output {
+	case "otlpgrpc", "otlphttp":
+		exporter, err = newOtlpTraceExporter(ctx, output, otlpCfg)
+	case "noop":
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(NoopWriter{}))
+	case "stdout":
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(&SlogWriter{Log: slog.Default(), Level: slog.LevelDebug}))
+	default:
+		return nil, fmt.Errorf("unknown trace output %s", output)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trace exporter: %w", err)
+	}
+
+	sampler, err := CreateTraceSampler(samplingCfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trace sampler: %w", err)
+	}
+
+	if redactor == nil {
+		redactor = &Redactor{}
+	}
+	exporter = &redactingSpanExporter{SpanExporter: exporter, redactor: redactor}
+	var processor trace.SpanProcessor = trace.NewBatchSpanProcessor(exporter)
+	if samplingCfg.TailCaptureEnabled() {
+		if processor, err = NewTailCaptureProcessor(processor, samplingCfg); err != nil {
+			return nil, fmt.Errorf("failed to create tail capture processor: %w", err)
 		}
 	}
 
 	tp := trace.NewTracerProvider(
-		trace.WithBatcher(exporter),
+		trace.WithSpanProcessor(processor),
+		trace.WithSampler(sampler),
 		trace.WithResource(res),
 	)
 
@@ -94,29 +138,33 @@ func ConfigureTraceProvider(ctx context.Context, res *resource.Resource, output
 	return tp, nil
 }
 
-func ConfigureMetricProvider(_ context.Context, res *resource.Resource, output string) (*metric.MeterProvider, error) {
+func ConfigureMetricProvider(ctx context.Context, res *resource.Resource, output string, otlpCfg OtlpExporterConfig) (*metric.MeterProvider, error) {
 	var reader metric.Reader
-	if output == "remote" {
+	var exporter metric.Exporter
+	var err error
+	switch output {
+	case "prometheus":
 		// recreate default registry to remove built-in collectors
 		// they are covered by otel
 		reg := prometheus.NewRegistry()
 		prometheus.DefaultRegisterer = reg
 		prometheus.DefaultGatherer = reg
-		var err error
 		if reader, err = promexporter.New(); err != nil {
 			return nil, fmt.Errorf("failed to create metric exporter: %w", err)
 		}
-	} else {
-		var writer io.Writer
-		if output == "noop" {
-			writer = NoopWriter{}
-		} else {
-			writer = &SlogWriter{Log: slog.Default(), Level: slog.LevelDebug}
-		}
-		exporter, err := stdoutmetric.New(stdoutmetric.WithWriter(writer))
-		if err != nil {
-			return nil, fmt.Errorf("failed to create metric exporter: %w", err)
-		}
+	case "otlpgrpc", "otlphttp":
+		exporter, err = newOtlpMetricExporter(ctx, output, otlpCfg)
+	case "noop":
+		exporter, err = stdoutmetric.New(stdoutmetric.WithWriter(NoopWriter{}))
+	case "stdout":
+		exporter, err = stdoutmetric.New(stdoutmetric.WithWriter(&SlogWriter{Log: slog.Default(), Level: slog.LevelDebug}))
+	default:
+		return nil, fmt.Errorf("unknown metric output %s", output)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create metric exporter: %w", err)
+	}
+	if reader == nil {
 		reader = metric.NewPeriodicReader(exporter)
 	}
 
@@ -134,37 +182,75 @@ func ConfigureMetricProvider(_ context.Context, res *resource.Resource, output s
 		return nil, fmt.Errorf("failed to start runtime observer: %w", err)
 	}
 
+	if err := RegisterBuildInfoMetric(); err != nil {
+		return nil, fmt.Errorf("failed to register build info metric: %w", err)
+	}
+
 	return mp, nil
 }
 
-// ConfigureLogProvider replace with OTEL log bridge when it's GA
-func ConfigureLogProvider(_ *resource.Resource, level string, format string) error {
+// ConfigureLogProvider sets up slog default logger writing to stderr and, for otlp outputs,
+// bridging the records to OTel log pipeline as well; returned LogLevels allow to change levels at runtime
+func ConfigureLogProvider(ctx context.Context, res *resource.Resource, level string, loggers map[string]string, format string, output string, otlpCfg OtlpExporterConfig, redactor *Redactor) (*sdklog.LoggerProvider, *LogLevels, error) {
 	lvl := slog.LevelDebug
 	if err := lvl.UnmarshalText([]byte(level)); err != nil {
 		fmt.Printf("failed to parse log level: %v, fallback to DEBUG", err)
 	}
+	loggerLevels := make(map[string]slog.Level, len(loggers))
+	for name, l := range loggers {
+		var loggerLvl slog.Level
+		if err := loggerLvl.UnmarshalText([]byte(l)); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse %s logger level: %w", name, err)
+		}
+		loggerLevels[name] = loggerLvl
+	}
+	levels := NewLogLevels(lvl, loggerLevels)
+
 	var handler slog.Handler
 	if format == "json" {
-		handler = slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: lvl})
+		handler = slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: minLogLevel})
 	} else {
 		handler = tint.NewHandler(os.Stderr, &tint.Options{
-			Level:      slog.LevelDebug,
+			Level:      minLogLevel,
 			TimeFormat: time.TimeOnly,
 		})
 	}
+	handler = slogotel.OtelHandler{Next: handler}
+
+	lpOptions := []sdklog.LoggerProviderOption{sdklog.WithResource(res)}
+	if output == "otlpgrpc" || output == "otlphttp" {
+		exporter, err := newOtlpLogExporter(ctx, output, otlpCfg)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create log exporter: %w", err)
+		}
+		lpOptions = append(lpOptions, sdklog.WithProcessor(sdklog.NewBatchProcessor(exporter)))
+	} else if output != "" && output != "stderr" {
+		return nil, nil, fmt.Errorf("unknown log output %s", output)
+	}
+	lp := sdklog.NewLoggerProvider(lpOptions...)
+	global.SetLoggerProvider(lp)
 
-	l := slog.New(slogotel.OtelHandler{Next: handler})
+	if output != "" && output != "stderr" {
+		handler = FanoutHandler{handler, otelslog.NewHandler("golang-http-service", otelslog.WithLoggerProvider(lp))}
+	}
+
+	if redactor == nil {
+		redactor = &Redactor{}
+	}
+	l := slog.New(NewLevelHandler(NewRedactingHandler(handler, redactor), levels))
 
 	slog.SetDefault(l)
-	otel.SetLogger(logr.FromSlogHandler(l.Handler()))
-	return nil
+	otel.SetLogger(logr.FromSlogHandler(NamedLogger("otel").Handler()))
+	return lp, levels, nil
 }
 
-func TelemetryHandler(checks ...health.Check) http.Handler {
+func TelemetryHandler(logLevels *LogLevels, checks ...health.Check) http.Handler {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/", HandleHTTPNotFound)
 	mux.Handle("/metrics", PrometheusHandler())
 	mux.Handle("/health", HealthCheckHandler(checks...))
+	mux.Handle("/info", InfoHandler())
+	mux.Handle("/loggers", logLevels.Handler())
 	h := RecoverMiddleware(mux)
 	return h
 }
@@ -213,37 +299,7 @@ func healthStatusListener(ctx context.Context, state health.CheckerState) {
 		g := slog.Group(name, cha...)
 		attrs = append(attrs, g)
 	}
-	slog.InfoContext(ctx, "health status changed", attrs...)
-}
-
-func logHTTPRequest(r *http.Request, m httpsnoop.Metrics) {
-	bytesIn, _ := strconv.Atoi(r.Header.Get("Content-Length"))
-	attrs := []any{
-		slog.String("host", r.Host),
-		slog.String("uri", r.RequestURI),
-		slog.String("method", r.Method),
-		slog.String("referer", r.Referer()),
-		slog.Int("status", m.Code),
-		slog.Int("bytesIn", bytesIn),
-		slog.Int64("bytesOut", m.Written),
-		slog.Duration("latency", m.Duration),
-	}
-
-	span := oteltrace.SpanFromContext(r.Context())
-	if readSpan, ok := span.(trace.ReadOnlySpan); ok {
-		for _, event := range readSpan.Events() {
-			if event.Name == semconv.ExceptionEventName {
-				var errAttrs []any
-				for _, a := range event.Attributes {
-					errAttrs = append(errAttrs, slog.String(string(a.Key), a.Value.AsString()))
-				}
-				attrs = append(attrs, slog.Group("err", errAttrs...))
-				break
-			}
-		}
-	}
-
-	slog.InfoContext(r.Context(), "access", attrs...)
+	NamedLogger("health").InfoContext(ctx, "health status changed", attrs...)
 }
 
 func labelRequest(ctx context.Context, requestID string) {
@@ -251,6 +307,7 @@ func labelRequest(ctx context.Context, requestID string) {
 
 	claims, ok := ctx.Value(jwtmiddleware.ContextKey{}).(*validator.ValidatedClaims)
 	if ok {
+		logctx.AddAttrs(ctx, slog.String("subject", claims.RegisteredClaims.Subject))
 		userIDAttr := semconv.EnduserIDKey.String(claims.RegisteredClaims.Subject)
 		spanAttrs = append(spanAttrs, userIDAttr)
 		audienceAttr := semconv.EnduserScopeKey.StringSlice(claims.RegisteredClaims.Audience)
@@ -261,6 +318,8 @@ func labelRequest(ctx context.Context, requestID string) {
 	if ok {
 		rolesAttr := semconv.EnduserRoleKey.StringSlice(roles)
 		spanAttrs = append(spanAttrs, rolesAttr)
+		setRedRole(ctx, roles)
+		logctx.AddAttrs(ctx, slog.Any("roles", roles))
 	}
 
 	// copy from
@@ -0,0 +1,55 @@
+package integration
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+func TestTelemetryResource_Should_Be_Created_With_All_Detectors(t *testing.T) {
+	detectors := []string{"container", "host", "process", "os", "sdk", "env"}
+
+	res, err := CreateTelemetryResource(context.Background(), "svc", "ns", "1.0.0", "test", detectors)
+
+	require.NoError(t, err)
+	assert.Equal(t, semconv.SchemaURL, res.SchemaURL())
+	assert.Contains(t, res.Attributes(), semconv.ServiceName("svc"))
+	assert.Contains(t, res.Attributes(), semconv.ServiceNamespace("ns"))
+	assert.Contains(t, res.Attributes(), semconv.DeploymentEnvironment("test"))
+	var hostSet bool
+	for _, a := range res.Attributes() {
+		hostSet = hostSet || a.Key == semconv.HostNameKey
+	}
+	assert.True(t, hostSet)
+}
+
+func TestTelemetryResource_Should_Reject_Unknown_Detector(t *testing.T) {
+	_, err := CreateTelemetryResource(context.Background(), "svc", "", "", "", []string{"gpu"})
+
+	assert.ErrorContains(t, err, "unknown resource detector gpu")
+}
+
+func TestTelemetryProviders_Should_Reject_Unknown_Output(t *testing.T) {
+	ctx := context.Background()
+
+	_, err := ConfigureTraceProvider(ctx, resource.Empty(), "remote", OtlpExporterConfig{}, TraceSamplingConfig{}, nil)
+	assert.ErrorContains(t, err, "unknown trace output remote")
+
+	_, err = ConfigureMetricProvider(ctx, resource.Empty(), "remote", OtlpExporterConfig{})
+	assert.ErrorContains(t, err, "unknown metric output remote")
+}
+
+func TestTelemetryProviders_Should_Export_Spans_Without_Redactor(t *testing.T) {
+	ctx := context.Background()
+	tp, err := ConfigureTraceProvider(ctx, resource.Empty(), "noop", OtlpExporterConfig{}, TraceSamplingConfig{}, nil)
+	require.NoError(t, err)
+
+	_, span := tp.Tracer("test").Start(ctx, "span")
+	span.End()
+
+	assert.NoError(t, tp.Shutdown(ctx))
+}
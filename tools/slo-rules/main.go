@@ -0,0 +1,47 @@
+// slo-rules generates prometheus recording and alerting rules from x-slo extensions of openapi operations
+package main
+
+import (
+	"flag"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang-http-service/api"
+	"golang-http-service/pkg/integration"
+)
+
+func main() {
+	out := flag.String("out", "slo-rules.yaml", "file to write rules to")
+	buckets := flag.String("buckets", "", "comma separated duration buckets in seconds, should match telemetry.metrics.durationBuckets config")
+	flag.Parse()
+
+	var durationBuckets []float64
+	if *buckets != "" {
+		for _, b := range strings.Split(*buckets, ",") {
+			v, err := strconv.ParseFloat(strings.TrimSpace(b), 64)
+			if err != nil {
+				slog.Error("failed to parse buckets", "err", err)
+				os.Exit(1)
+			}
+			durationBuckets = append(durationBuckets, v)
+		}
+	}
+
+	swagger, err := api.GetSwagger()
+	if err != nil {
+		slog.Error("failed to get embedded swagger spec", "err", err)
+		os.Exit(1)
+	}
+	rules, err := integration.SLORules(swagger, durationBuckets)
+	if err != nil {
+		slog.Error("failed to generate rules", "err", err)
+		os.Exit(1)
+	}
+	header := []byte("# generated by tools/slo-rules from x-slo extensions in api/openapi.yaml; DO NOT EDIT\n")
+	if err := os.WriteFile(*out, append(header, rules...), 0o644); err != nil {
+		slog.Error("failed to write rules", "err", err)
+		os.Exit(1)
+	}
+}